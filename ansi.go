@@ -0,0 +1,149 @@
+package silog
+
+import (
+	"bytes"
+	"strings"
+	"unicode"
+
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/colorprofile"
+)
+
+// hasColor reports whether output with the given profile supports color.
+// An unknown profile is assumed to support color.
+func hasColor(p colorprofile.Profile) bool {
+	return p != colorprofile.NoTTY && p != colorprofile.Ascii
+}
+
+// ansiResets are the escape sequences that reset all text attributes.
+var ansiResets = [][]byte{
+	[]byte("\x1b[m"),
+	[]byte("\x1b[0m"),
+}
+
+// styleStart returns the escape sequence that a style
+// writes before the text it renders.
+//
+// This is empty if the style does not affect rendered text.
+func styleStart(style lipgloss.Style) string {
+	const marker = "x"
+
+	rendered := style.UnsetString().Render(marker)
+	start, _, ok := strings.Cut(rendered, marker)
+	if !ok {
+		return ""
+	}
+	return start
+}
+
+// appendStyledLines appends src to dst,
+// wrapping each line in src with the given style.
+//
+// Unlike lipgloss.Style.Render, styled segments inside src are retained:
+// the style is re-applied after each reset sequence in src
+// so that it continues to apply to the rest of the line.
+func appendStyledLines(dst, src []byte, style lipgloss.Style) []byte {
+	start := styleStart(style)
+	if start == "" {
+		return append(dst, src...)
+	}
+
+	for line := range bytes.Lines(src) {
+		line, newline := bytes.CutSuffix(line, []byte("\n"))
+		if len(line) == 0 {
+			// Nothing to style.
+			if newline {
+				dst = append(dst, '\n')
+			}
+			continue
+		}
+
+		dst = append(dst, start...)
+		for len(line) > 0 {
+			idx, n := indexReset(line)
+			if idx < 0 {
+				dst = append(dst, line...)
+				break
+			}
+
+			dst = append(dst, line[:idx+n]...)
+			dst = append(dst, start...)
+			line = line[idx+n:]
+		}
+		dst = append(dst, ansiResets[0]...)
+
+		if newline {
+			dst = append(dst, '\n')
+		}
+	}
+
+	return dst
+}
+
+// indexReset returns the index of the first reset sequence in bs
+// and the length of that sequence, or -1 if there isn't one.
+func indexReset(bs []byte) (idx, n int) {
+	idx = -1
+	for _, reset := range ansiResets {
+		if i := bytes.Index(bs, reset); i >= 0 && (idx < 0 || i < idx) {
+			idx, n = i, len(reset)
+		}
+	}
+	return idx, n
+}
+
+// highlightPairs returns line with the keys of key=value words
+// rendered with the given style.
+// Words that are not clearly key=value pairs are left as-is.
+func highlightPairs(line string, keyStyle lipgloss.Style) string {
+	var out strings.Builder
+	for len(line) > 0 {
+		// Copy leading whitespace as-is.
+		if i := strings.IndexFunc(line, isNotSpace); i != 0 {
+			if i < 0 {
+				i = len(line)
+			}
+			out.WriteString(line[:i])
+			line = line[i:]
+			continue
+		}
+
+		word := line
+		if i := strings.IndexFunc(line, unicode.IsSpace); i >= 0 {
+			word = line[:i]
+		}
+		line = line[len(word):]
+
+		key, value, ok := strings.Cut(word, "=")
+		if ok && isPairKey(key) && value != "" && !strings.Contains(value, "=") {
+			out.WriteString(keyStyle.Render(key))
+			out.WriteString("=")
+			out.WriteString(value)
+		} else {
+			out.WriteString(word)
+		}
+	}
+
+	return out.String()
+}
+
+// isPairKey reports whether s looks like the key of a key=value pair
+// in a message or error string:
+// an identifier made of letters, digits, '_', '.', and '-'
+// that does not start with a digit, '.', or '-'.
+//
+// It's used by both HighlightMessagePairs and PromoteErrorFields
+// so that they agree on what a key is.
+func isPairKey(s string) bool {
+	for i, r := range s {
+		switch {
+		case r == '_' || unicode.IsLetter(r):
+		case i > 0 && (r == '.' || r == '-' || unicode.IsDigit(r)):
+		default:
+			return false
+		}
+	}
+	return s != ""
+}
+
+func isNotSpace(r rune) bool { return !unicode.IsSpace(r) }
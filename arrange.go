@@ -0,0 +1,200 @@
+package silog
+
+import (
+	"bytes"
+	"cmp"
+	"log/slog"
+	"slices"
+	"strings"
+)
+
+// groupedAttr is a non-group attribute and the groups it's nested in.
+//
+// The attribute has already been resolved and passed through ReplaceAttr.
+type groupedAttr struct {
+	groups []string // must not be modified
+	attr   slog.Attr
+}
+
+// fullKey returns the key of the attribute
+// prefixed with its groups, e.g. "a.b.key".
+func (a groupedAttr) fullKey() string {
+	var key strings.Builder
+	for _, group := range a.groups {
+		if group != "" {
+			key.WriteString(group)
+			key.WriteString(groupDelim)
+		}
+	}
+	key.WriteString(a.attr.Key)
+	return key.String()
+}
+
+// collectAttrs appends the given attributes to dst
+// without serializing them,
+// expanding groups into their members.
+func (f *attrFormatter) collectAttrs(dst []groupedAttr, attrs []slog.Attr) []groupedAttr {
+	for _, attr := range attrs {
+		f.walkAttr(attr, func(groups []string, attr slog.Attr) {
+			dst = append(dst, groupedAttr{
+				groups: slices.Clone(groups),
+				attr:   attr,
+			})
+		})
+	}
+	return dst
+}
+
+// arrangeAttrs returns the handler's attributes
+// and the attributes of the given record
+// arranged as requested by the handler's options.
+func (h *Handler) arrangeAttrs(rec slog.Record, view recordView) []groupedAttr {
+	f := h.attrFormatter(nil)
+
+	attrs := make([]groupedAttr, 0, len(h.groupedAttrs)+rec.NumAttrs())
+	attrs = append(attrs, h.groupedAttrs...)
+	rec.Attrs(func(attr slog.Attr) bool {
+		attrs = f.collectAttrs(attrs, []slog.Attr{attr})
+		return true
+	})
+
+	if view.summary {
+		attrs = slices.DeleteFunc(attrs, func(a groupedAttr) bool {
+			return !slices.Contains(h.summaryKeys, a.fullKey())
+		})
+	}
+
+	if h.groupAttrs {
+		clusterGroups(attrs)
+	}
+
+	if len(h.attrPriority) > 0 {
+		prioritizeAttrs(attrs, h.attrPriority)
+	}
+
+	if !view.ref.Equal(slog.Attr{}) {
+		attrs = append(attrs, groupedAttr{attr: view.ref})
+	}
+
+	return attrs
+}
+
+// takeLeadingAttrs removes attributes listed in leadingAttrs from attrs,
+// and returns their rendered values separated by spaces.
+// Attributes with multi-line values are left in place.
+//
+// The returned buffer was taken from the handler's buffer pool.
+// The caller must release it.
+func (h *Handler) takeLeadingAttrs(lvl slog.Level, attrs []groupedAttr) ([]groupedAttr, []byte) {
+	leading := *takeBuf(h.bufPool)
+	f := h.attrFormatter(nil)
+	f.lvl = lvl
+
+	type leadingAttr struct {
+		rank int // index in leadingAttrs
+		key  string
+		val  []byte
+	}
+	var found []leadingAttr
+	attrs = slices.DeleteFunc(attrs, func(a groupedAttr) bool {
+		rank := slices.Index(h.leadingAttrs, a.fullKey())
+		if rank < 0 {
+			return false
+		}
+
+		switch a.attr.Value.Any().(type) {
+		case blockValue, readerValue:
+			return false // always multi-line
+		}
+		val := f.appendValue(nil, a.attr.Value)
+		if bytes.ContainsAny(val, "\r\n") {
+			return false
+		}
+
+		found = append(found, leadingAttr{rank: rank, key: a.attr.Key, val: val})
+		return true
+	})
+
+	slices.SortStableFunc(found, func(a, b leadingAttr) int {
+		return cmp.Compare(a.rank, b.rank)
+	})
+	for i, a := range found {
+		if i > 0 {
+			leading = append(leading, ' ')
+		}
+		if style, ok := f.valueStyle(a.key); ok {
+			leading = append(leading, style.Render(string(a.val))...)
+		} else {
+			leading = append(leading, a.val...)
+		}
+	}
+
+	return attrs, leading
+}
+
+// clusterGroups stably reorders attrs
+// so that attributes in the same group are adjacent.
+//
+// At each level of nesting, groups and attributes
+// are ordered by where they first appear in attrs.
+func clusterGroups(attrs []groupedAttr) {
+	// Each attribute gets a sort key with one element per group it's in,
+	// and a final element for the attribute itself.
+	// Each element is the position of the first attribute
+	// in the group (or the attribute itself) at that level.
+	// Comparing these keys lexicographically orders attributes
+	// like a depth-first walk of the group tree.
+	type sortable struct {
+		key  []int
+		attr groupedAttr
+	}
+
+	firstSeen := make(map[string]int) // group path => index
+	items := make([]sortable, len(attrs))
+	for i, a := range attrs {
+		var (
+			key  []int
+			path strings.Builder
+		)
+		for _, group := range a.groups {
+			if group == "" {
+				continue // inlined group
+			}
+			path.WriteString(group)
+			path.WriteByte(0)
+
+			idx, ok := firstSeen[path.String()]
+			if !ok {
+				idx = i
+				firstSeen[path.String()] = i
+			}
+			key = append(key, idx)
+		}
+		key = append(key, i)
+
+		items[i] = sortable{key: key, attr: a}
+	}
+
+	slices.SortStableFunc(items, func(a, b sortable) int {
+		return slices.Compare(a.key, b.key)
+	})
+	for i, item := range items {
+		attrs[i] = item.attr
+	}
+}
+
+// prioritizeAttrs reorders attrs in-place so that attributes
+// listed in priority come first, in the order they're listed.
+// Other attributes retain their relative order.
+func prioritizeAttrs(attrs []groupedAttr, priority []string) {
+	rank := func(a groupedAttr) int {
+		if i := slices.Index(priority, a.fullKey()); i >= 0 {
+			return i
+		}
+		return len(priority)
+	}
+
+	slices.SortStableFunc(attrs, func(a, b groupedAttr) int {
+		return cmp.Compare(rank(a), rank(b))
+	})
+}
@@ -0,0 +1,53 @@
+package silog
+
+import (
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/colorprofile"
+)
+
+// DetectColorProfile reports the color profile of output written to w
+// given the environment variables in env (in the form of os.Environ).
+//
+// It is colorprofile.Detect with support for FORCE_COLOR:
+// if FORCE_COLOR is set to a non-empty value
+// other than one that strconv.ParseBool reads as false (e.g. "0", "false"),
+// output is assumed to support color even if w is not a terminal,
+// e.g. when piping to "less -R".
+// FORCE_COLOR=2 and FORCE_COLOR=3 select 256 colors and true color.
+//
+// NO_COLOR takes precedence over FORCE_COLOR.
+func DetectColorProfile(w io.Writer, env []string) colorprofile.Profile {
+	profile := colorprofile.Detect(w, env)
+	if hasColor(profile) || lookupEnv(env, "NO_COLOR") != "" {
+		return profile
+	}
+
+	force := lookupEnv(env, "FORCE_COLOR")
+	if enabled, err := strconv.ParseBool(force); force == "" || (err == nil && !enabled) {
+		return profile
+	}
+
+	switch force {
+	case "2":
+		return colorprofile.ANSI256
+	case "3":
+		return colorprofile.TrueColor
+	default:
+		return colorprofile.ANSI
+	}
+}
+
+// lookupEnv returns the value of the environment variable
+// with the given name in env, or an empty string if it's not set.
+// If the variable is set more than once, the last value wins.
+func lookupEnv(env []string, name string) string {
+	for i := len(env) - 1; i >= 0; i-- {
+		if value, ok := strings.CutPrefix(env[i], name+"="); ok {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,50 @@
+package silog_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/colorprofile"
+	"github.com/stretchr/testify/assert"
+	"go.abhg.dev/log/silog"
+)
+
+func TestDetectColorProfile(t *testing.T) {
+	tests := []struct {
+		name string
+		env  []string
+		want colorprofile.Profile
+	}{
+		{name: "NoEnv", want: colorprofile.NoTTY},
+		{name: "ForceColor", env: []string{"FORCE_COLOR=1"}, want: colorprofile.ANSI},
+		{name: "ForceColorTrue", env: []string{"FORCE_COLOR=true"}, want: colorprofile.ANSI},
+		{name: "ForceColor256", env: []string{"FORCE_COLOR=2"}, want: colorprofile.ANSI256},
+		{name: "ForceTrueColor", env: []string{"FORCE_COLOR=3"}, want: colorprofile.TrueColor},
+		{name: "ForceColorEmpty", env: []string{"FORCE_COLOR="}, want: colorprofile.NoTTY},
+		{name: "ForceColorZero", env: []string{"FORCE_COLOR=0"}, want: colorprofile.NoTTY},
+		{name: "ForceColorFalse", env: []string{"FORCE_COLOR=false"}, want: colorprofile.NoTTY},
+		{
+			name: "ForceColorLastWins",
+			env:  []string{"FORCE_COLOR=3", "FORCE_COLOR=1"},
+			want: colorprofile.ANSI,
+		},
+		{
+			name: "NoColorPrecedence",
+			env:  []string{"FORCE_COLOR=1", "NO_COLOR=1"},
+			want: colorprofile.NoTTY,
+		},
+		{
+			name: "NoColorAnyValue",
+			env:  []string{"NO_COLOR=yes", "FORCE_COLOR=1"},
+			want: colorprofile.NoTTY,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// strings.Builder is not a terminal.
+			got := silog.DetectColorProfile(new(strings.Builder), tt.env)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
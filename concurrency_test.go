@@ -1,6 +1,7 @@
 package silog_test
 
 import (
+	"io"
 	"log/slog"
 	"regexp"
 	"strconv"
@@ -58,3 +59,45 @@ func TestHandler_concurrentWrites(t *testing.T) {
 			"incorrect number of messages logged for message %d", msgIdx)
 	}
 }
+
+func TestHandler_concurrentLevelWriters(t *testing.T) {
+	var stdout, stderr strings.Builder
+
+	// stderr is used for two levels,
+	// so writes to it at both levels must be synchronized together.
+	handler := silog.NewHandler(&stdout, &silog.HandlerOptions{
+		Level: slog.LevelDebug,
+		Style: silog.PlainStyle(),
+		LevelWriters: map[slog.Level]io.Writer{
+			slog.LevelWarn:  &stderr,
+			slog.LevelError: &stderr,
+		},
+	})
+	logger := slog.New(handler)
+
+	const NumWorkers, NumMessages = 10, 100
+
+	var wg sync.WaitGroup
+	wg.Add(NumWorkers)
+	for workerIdx := range NumWorkers {
+		go func() {
+			defer wg.Done()
+
+			for range NumMessages {
+				switch workerIdx % 3 {
+				case 0:
+					logger.Info("Hello")
+				case 1:
+					logger.Warn("Hello")
+				default:
+					logger.Error("Hello")
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 4*NumMessages, strings.Count(stdout.String(), "INF Hello"))
+	assert.Equal(t, 3*NumMessages, strings.Count(stderr.String(), "WRN Hello"))
+	assert.Equal(t, 3*NumMessages, strings.Count(stderr.String(), "ERR Hello"))
+}
@@ -0,0 +1,87 @@
+package silog
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+)
+
+// levelCounts counts records written at each level.
+// It is shared between a handler and handlers derived from it.
+type levelCounts struct {
+	m sync.Map // slog.Level => *atomic.Uint64
+}
+
+// add records that a record was written at the given level.
+func (c *levelCounts) add(lvl slog.Level) {
+	n, ok := c.m.Load(lvl)
+	if !ok {
+		n, _ = c.m.LoadOrStore(lvl, new(atomic.Uint64))
+	}
+	n.(*atomic.Uint64).Add(1)
+}
+
+// Counts returns the number of records written at each level
+// by this handler and all handlers that share its output
+// (e.g. those made with WithAttrs, WithPrefix, etc.).
+//
+// Levels are reported as displayed, after any level offset.
+// Levels with no records are omitted.
+//
+// It returns nil if HandlerOptions.CountLevels was not set.
+func (h *Handler) Counts() map[slog.Level]int {
+	if h.counts == nil {
+		return nil
+	}
+
+	counts := make(map[slog.Level]int)
+	h.counts.m.Range(func(k, v any) bool {
+		counts[k.(slog.Level)] = int(v.(*atomic.Uint64).Load())
+		return true
+	})
+	return counts
+}
+
+// WriteSummary writes a summary of the number of
+// warnings and errors written by this handler to w.
+// For example:
+//
+//	3 warnings, 1 error
+//
+// Records at [slog.LevelError] and above count as errors,
+// and records at [slog.LevelWarn] and above, but below errors,
+// count as warnings.
+// The summary is not terminated with a newline.
+//
+// Use it at the end of a program to report on the run:
+//
+//	fmt.Fprint(os.Stderr, "completed with ")
+//	handler.WriteSummary(os.Stderr)
+//	fmt.Fprintln(os.Stderr)
+//
+// HandlerOptions.CountLevels must be set for the counts to be accurate.
+func (h *Handler) WriteSummary(w io.Writer) error {
+	var warnings, errors int
+	for lvl, n := range h.Counts() {
+		switch {
+		case lvl >= slog.LevelError:
+			errors += n
+		case lvl >= slog.LevelWarn:
+			warnings += n
+		}
+	}
+
+	_, err := fmt.Fprintf(w, "%d %s, %d %s",
+		warnings, plural(warnings, "warning", "warnings"),
+		errors, plural(errors, "error", "errors"))
+	return err
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
@@ -0,0 +1,68 @@
+package silog_test
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.abhg.dev/log/silog"
+)
+
+func TestHandler_Counts(t *testing.T) {
+	handler := silog.NewHandler(io.Discard, &silog.HandlerOptions{
+		Level:       slog.LevelDebug,
+		CountLevels: true,
+	})
+	assert.Empty(t, handler.Counts())
+
+	log := slog.New(handler)
+	log.Debug("foo")
+	log.Info("bar")
+	log.With("k", "v").Warn("baz")
+	log.Warn("qux")
+	slog.New(handler.WithPrefix("p")).Error("quux")
+	slog.New(handler.WithLevelOffset(1)).Warn("offset")
+	slog.New(handler.WithLevel(slog.LevelError)).Info("not logged, not counted")
+
+	assert.Equal(t, map[slog.Level]int{
+		slog.LevelDebug:    1,
+		slog.LevelInfo:     1,
+		slog.LevelWarn:     2,
+		slog.LevelWarn + 1: 1,
+		slog.LevelError:    1,
+	}, handler.Counts())
+
+	var summary strings.Builder
+	assert.NoError(t, handler.WriteSummary(&summary))
+	assert.Equal(t, "3 warnings, 1 error", summary.String())
+}
+
+func TestHandler_Counts_disabled(t *testing.T) {
+	handler := silog.NewHandler(io.Discard, nil)
+	slog.New(handler).Error("foo")
+	assert.Nil(t, handler.Counts())
+
+	var summary strings.Builder
+	assert.NoError(t, handler.WriteSummary(&summary))
+	assert.Equal(t, "0 warnings, 0 errors", summary.String())
+}
+
+func TestHandler_Counts_writeError(t *testing.T) {
+	var fail bool
+	handler := silog.NewHandler(writerFunc(func(p []byte) (int, error) {
+		if fail {
+			return 0, errors.New("great sadness")
+		}
+		return len(p), nil
+	}), &silog.HandlerOptions{CountLevels: true})
+	log := slog.New(handler)
+
+	log.Error("foo")
+	fail = true
+	log.Error("bar")
+
+	assert.Equal(t, map[slog.Level]int{slog.LevelError: 1}, handler.Counts())
+}
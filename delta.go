@@ -0,0 +1,41 @@
+package silog
+
+import (
+	"sync"
+	"time"
+)
+
+// deltaClock tracks the time of the previous record
+// for HandlerOptions.ShowTimeDelta.
+//
+// It is shared between a handler and handlers derived from it.
+type deltaClock struct {
+	// mu must be held while rendering and writing a record
+	// so that "previous record" is well-defined.
+	mu sync.Mutex
+
+	prev time.Time
+}
+
+// delta returns the time since the previous record, e.g. "+12ms",
+// and makes t the time of the previous record.
+// It returns an empty string for the first record,
+// and for records without a time.
+func (c *deltaClock) delta(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	prev := c.prev
+	c.prev = t
+	if prev.IsZero() {
+		return ""
+	}
+
+	var bs []byte
+	d := t.Sub(prev)
+	if d >= 0 {
+		bs = append(bs, '+')
+	}
+	return string(appendAdaptiveDuration(bs, d))
+}
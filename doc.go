@@ -19,4 +19,14 @@
 // Then use it with a slog.Logger:
 //
 //	logger := slog.New(handler)
+//
+// # Testing
+//
+// Styles always render ANSI escape codes,
+// regardless of the terminal or environment,
+// so colored output is deterministic and can be compared in tests
+// without configuring a renderer or color profile.
+// Use [PlainStyle] to test output without escape codes,
+// or render expected values with the same lipgloss styles
+// used in the [Style] to test colored output.
 package silog
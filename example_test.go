@@ -2,8 +2,11 @@ package silog_test
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
 	"os"
+	"strings"
+	"time"
 
 	"charm.land/lipgloss/v2"
 	"go.abhg.dev/log/silog"
@@ -99,6 +102,84 @@ func ExampleHandler_WithLevelOffset() {
 	// DBG Downgraded to debug
 }
 
+// Demonstrates a configuration suitable for long-running servers.
+func ExampleServerOptions() {
+	opts := silog.ServerOptions()
+	// To keep the test output stable,
+	// we will log a fixed time in this example.
+	opts.ReplaceAttr = func(groups []string, attr slog.Attr) slog.Attr {
+		if len(groups) == 0 && attr.Key == slog.TimeKey {
+			return slog.Time(slog.TimeKey, time.Date(2025, 1, 2, 15, 4, 5, 678_000_000, time.UTC))
+		}
+		return attr
+	}
+
+	logger := slog.New(silog.NewHandler(os.Stdout, opts))
+	logger.Info("Request handled", "status", 200)
+
+	// Output:
+	// 15:04:05.678 INF Request handled  status=200
+}
+
+func ExampleNewCLILogger() {
+	// os.Stdout is not a terminal when running examples,
+	// so output is not colored.
+	logger := silog.NewCLILogger(os.Stdout, slog.LevelDebug)
+	logger.Debug("Reading config", "path", "config.yaml")
+	logger.Warn("Config not found, using defaults")
+
+	// Output:
+	// DBG Reading config  path=config.yaml
+	// WRN Config not found, using defaults
+}
+
+func ExampleLazy() {
+	logger := slog.New(silog.NewHandler(os.Stdout, &silog.HandlerOptions{
+		Level:       slog.LevelInfo,
+		Style:       silog.PlainStyle(),
+		ReplaceAttr: skipTime,
+	}))
+
+	summary := func() any {
+		fmt.Println("(computing summary)")
+		return "3 items"
+	}
+
+	// Debug is not enabled, so the summary is not computed.
+	logger.Debug("Loaded cart", "summary", silog.Lazy(summary))
+	logger.Info("Checked out", "summary", silog.Lazy(summary))
+
+	// Output:
+	// (computing summary)
+	// INF Checked out  summary=3 items
+}
+
+// Demonstrates how to test colored output.
+// Styles always render escape codes, so no setup is needed.
+func Example_testColors() {
+	bold := lipgloss.NewStyle().Bold(true)
+
+	style := silog.PlainStyle()
+	style.Messages[slog.LevelInfo] = bold
+
+	var buffer strings.Builder
+	handler := silog.NewHandler(&buffer, &silog.HandlerOptions{
+		Style: style,
+		// To keep the test output clean easy to test,
+		// we will not log the time in this example.
+		ReplaceAttr: skipTime,
+	})
+	slog.New(handler).Info("hello")
+
+	want := "INF " + bold.Render("hello") + "\n"
+	fmt.Printf("%q\n", buffer.String())
+	fmt.Println(buffer.String() == want)
+
+	// Output:
+	// "INF \x1b[1mhello\x1b[m\n"
+	// true
+}
+
 func skipTime(groups []string, attr slog.Attr) slog.Attr {
 	if len(groups) == 0 && attr.Key == slog.TimeKey {
 		return slog.Attr{}
@@ -0,0 +1,59 @@
+package silog
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+)
+
+// fallbackMsgLen is the maximum number of runes of a message
+// included in a fallback diagnostic.
+const fallbackMsgLen = 60
+
+// errorFallback writes diagnostics for records
+// that could not be written to a handler's output.
+// It's shared by a Handler and handlers derived from it.
+type errorFallback struct {
+	w io.Writer
+
+	// mu is held while writing a diagnostic.
+	mu sync.Mutex
+
+	// writer is the ID of the goroutine writing a diagnostic,
+	// or zero if none is being written.
+	// A fallback writer that logs to the same handler
+	// calls write again from that goroutine,
+	// so such calls are skipped to avoid recursing forever.
+	writer atomic.Uint64
+}
+
+// write reports that a record at lvl with the given message
+// could not be written because of err.
+// Errors from the fallback writer are ignored.
+func (f *errorFallback) write(lvl slog.Level, msg string, err error) {
+	id := goroutineID()
+	if id == 0 {
+		// Can't tell recursive calls apart.
+		// Don't risk a deadlock.
+		if !f.mu.TryLock() {
+			return
+		}
+	} else {
+		if f.writer.Load() == id {
+			// Already writing a diagnostic on this goroutine.
+			return
+		}
+		f.mu.Lock()
+	}
+	defer f.mu.Unlock()
+
+	f.writer.Store(id)
+	defer f.writer.Store(0)
+
+	if runes := []rune(msg); len(runes) > fallbackMsgLen {
+		msg = string(runes[:fallbackMsgLen]) + "…"
+	}
+	_, _ = fmt.Fprintf(f.w, "silog: dropped %v record %q: %v\n", lvl, msg, err)
+}
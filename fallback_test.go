@@ -0,0 +1,93 @@
+package silog_test
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.abhg.dev/log/silog"
+)
+
+func TestHandler_errorFallbackWriter(t *testing.T) {
+	failing := writerFunc(func([]byte) (int, error) {
+		return 0, errors.New("great sadness")
+	})
+
+	var fallback strings.Builder
+	log := slog.New(silog.NewHandler(failing, &silog.HandlerOptions{
+		ErrorFallbackWriter: &fallback,
+	}))
+
+	log.Error("connection lost")
+	log.Info(strings.Repeat("x", 100))
+
+	assert.Equal(t,
+		`silog: dropped ERROR record "connection lost": great sadness`+"\n"+
+			`silog: dropped INFO record "`+strings.Repeat("x", 60)+`…": great sadness`+"\n",
+		fallback.String())
+}
+
+func TestHandler_errorFallbackWriter_recursive(t *testing.T) {
+	failing := writerFunc(func([]byte) (int, error) {
+		return 0, errors.New("great sadness")
+	})
+
+	var (
+		fallback strings.Builder
+		log      *slog.Logger
+	)
+	log = slog.New(silog.NewHandler(failing, &silog.HandlerOptions{
+		ErrorFallbackWriter: writerFunc(func(p []byte) (int, error) {
+			// Logging to the failing handler must not recurse.
+			log.Error("fallback")
+			return fallback.Write(p)
+		}),
+	}))
+
+	log.Error("foo")
+	assert.Equal(t, `silog: dropped ERROR record "foo": great sadness`+"\n", fallback.String())
+}
+
+func TestHandler_errorFallbackWriter_concurrent(t *testing.T) {
+	failing := writerFunc(func([]byte) (int, error) {
+		return 0, errors.New("great sadness")
+	})
+
+	var (
+		mu    sync.Mutex
+		lines int
+	)
+	log := slog.New(silog.NewHandler(failing, &silog.HandlerOptions{
+		ErrorFallbackWriter: writerFunc(func(p []byte) (int, error) {
+			time.Sleep(time.Millisecond) // overlap with other goroutines
+			mu.Lock()
+			defer mu.Unlock()
+			lines++
+			return len(p), nil
+		}),
+	}))
+
+	const NumWorkers = 10
+	var wg sync.WaitGroup
+	for range NumWorkers {
+		wg.Go(func() { log.Error("foo") })
+	}
+	wg.Wait()
+
+	assert.Equal(t, NumWorkers, lines, "no diagnostics dropped")
+}
+
+func TestHandler_errorFallbackWriter_success(t *testing.T) {
+	var fallback strings.Builder
+	log := slog.New(silog.NewHandler(io.Discard, &silog.HandlerOptions{
+		ErrorFallbackWriter: &fallback,
+	}))
+
+	log.Error("foo")
+	assert.Empty(t, fallback.String())
+}
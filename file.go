@@ -0,0 +1,119 @@
+package silog
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// FileHandler is a [Handler] that writes to a file that it owns.
+//
+// Use it to log to a file without additional setup:
+//
+//	handler, err := silog.NewFileHandler(logPath, nil)
+//	if err != nil {
+//		return err
+//	}
+//	defer handler.Close()
+//
+// Call Rotate to reopen the file at the same path,
+// e.g. after logrotate moves it and sends SIGHUP.
+//
+// Handlers derived from a FileHandler (e.g. with WithAttrs)
+// write to the same file, and are affected by Rotate and Close.
+type FileHandler struct {
+	*Handler
+
+	file *fileWriter
+}
+
+// NewFileHandler opens the file at path for appending,
+// creating it and its parent directories if necessary,
+// and returns a handler that writes to it.
+//
+// If opts.Style is unset, [PlainStyle] is used
+// as log files are rarely read in a terminal.
+//
+// The caller must call Close when the handler is no longer needed.
+func NewFileHandler(path string, opts *HandlerOptions) (*FileHandler, error) {
+	f, err := openLogFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	newOpts := *cmp.Or(opts, &HandlerOptions{})
+	newOpts.Style = cmp.Or(newOpts.Style, PlainStyle())
+
+	file := &fileWriter{path: path, f: f}
+	return &FileHandler{
+		Handler: NewHandler(file, &newOpts),
+		file:    file,
+	}, nil
+}
+
+// Rotate reopens the file at the original path.
+// Records logged after Rotate returns are written to the new file.
+//
+// If the file cannot be reopened,
+// the handler continues to write to the old file.
+func (h *FileHandler) Rotate() error {
+	h.outMu.Lock()
+	defer h.outMu.Unlock()
+
+	if h.file.f == nil {
+		return os.ErrClosed
+	}
+
+	f, err := openLogFile(h.file.path)
+	if err != nil {
+		return err
+	}
+
+	old := h.file.f
+	h.file.f = f
+	return old.Close()
+}
+
+// Close flushes and closes the file.
+// Records logged after Close are dropped
+// and their Handle calls return an error.
+func (h *FileHandler) Close() error {
+	h.outMu.Lock()
+	defer h.outMu.Unlock()
+
+	if h.file.f == nil {
+		return os.ErrClosed
+	}
+
+	f := h.file.f
+	h.file.f = nil
+	return errors.Join(f.Sync(), f.Close())
+}
+
+// fileWriter is the output of a FileHandler.
+// It must only be used with the handler's output lock held.
+type fileWriter struct {
+	path string
+	f    *os.File // nil if closed
+}
+
+func (w *fileWriter) Write(p []byte) (int, error) {
+	if w.f == nil {
+		return 0, os.ErrClosed
+	}
+	return w.f.Write(p)
+}
+
+func openLogFile(path string) (*os.File, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("create log directory: %w", err)
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open log file: %w", err)
+	}
+	return f, nil
+}
@@ -0,0 +1,107 @@
+package silog_test
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.abhg.dev/log/silog"
+)
+
+func TestFileHandler(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app", "logs", "app.log")
+
+	handler, err := silog.NewFileHandler(path, &silog.HandlerOptions{
+		ReplaceAttr: skipTime,
+	})
+	require.NoError(t, err)
+
+	log := slog.New(handler).With("k", "v")
+	log.Info("foo")
+
+	// Simulate logrotate moving the file away.
+	rotated := path + ".1"
+	require.NoError(t, os.Rename(path, rotated))
+	log.Info("bar") // still goes to the old file
+	require.NoError(t, handler.Rotate())
+	log.Info("baz")
+
+	require.NoError(t, handler.Close())
+	assert.ErrorIs(t, handler.Close(), os.ErrClosed)
+	assert.ErrorIs(t, handler.Rotate(), os.ErrClosed)
+	assert.Error(t, handler.Handle(t.Context(), slog.Record{Message: "qux"}))
+
+	got, err := os.ReadFile(rotated)
+	require.NoError(t, err)
+	assert.Equal(t, "INF foo  k=v\nINF bar  k=v\n", string(got))
+
+	got, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "INF baz  k=v\n", string(got))
+}
+
+func TestFileHandler_append(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	require.NoError(t, os.WriteFile(path, []byte("existing\n"), 0o644))
+
+	handler, err := silog.NewFileHandler(path, &silog.HandlerOptions{
+		ReplaceAttr: skipTime,
+	})
+	require.NoError(t, err)
+	slog.New(handler).Warn("foo")
+	require.NoError(t, handler.Close())
+
+	got, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "existing\nWRN foo\n", string(got))
+}
+
+func TestFileHandler_openError(t *testing.T) {
+	dir := t.TempDir()
+	notDir := filepath.Join(dir, "file")
+	require.NoError(t, os.WriteFile(notDir, nil, 0o644))
+
+	_, err := silog.NewFileHandler(filepath.Join(notDir, "app.log"), nil)
+	assert.ErrorContains(t, err, "create log directory")
+}
+
+func TestFileHandler_writeTimeoutRotate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+
+	// A timeout this short makes most writes time out
+	// and finish in the background,
+	// concurrently with Rotate and Close.
+	handler, err := silog.NewFileHandler(path, &silog.HandlerOptions{
+		ReplaceAttr:  skipTime,
+		WriteTimeout: time.Nanosecond,
+	})
+	require.NoError(t, err)
+	log := slog.New(handler)
+
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Go(func() {
+			for range 100 {
+				log.Info("foo")
+			}
+		})
+	}
+	for range 10 {
+		assert.NoError(t, handler.Rotate())
+	}
+	wg.Wait()
+
+	require.NoError(t, handler.Close())
+	got, err := os.ReadFile(path)
+	require.NoError(t, err)
+	for line := range strings.Lines(string(got)) {
+		assert.Equal(t, "INF foo\n", line)
+	}
+}
@@ -0,0 +1,98 @@
+package silog
+
+import (
+	"log/slog"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Format is the output format of a [Handler].
+type Format int
+
+const (
+	// FormatText is the default human-readable format.
+	FormatText Format = iota
+
+	// FormatTSV writes each record as a single line
+	// of tab-separated fields, in the following order:
+	//
+	//	time, level, prefix, message, attributes
+	//
+	// Every record has all five fields, even if some are empty,
+	// so that columns line up for tools like `column -t`.
+	// Fields are escaped so that they never contain tabs or newlines:
+	//
+	//   - in the time, level, prefix, and message fields,
+	//     backslash, tab, newline, and carriage return
+	//     are written as \\, \t, \n, and \r
+	//   - the attributes field is a list of key=value pairs
+	//     separated by spaces, as in logfmt;
+	//     keys include their groups (e.g. "req.id"),
+	//     and values that are empty or contain spaces, quotes, '=',
+	//     or non-printable characters are quoted with Go syntax
+	//     (see strconv.Quote)
+	//
+	// ReplaceAttr is honored as in FormatText.
+	// Style is used only for the level label,
+	// so use FormatTSV with [PlainStyle].
+	// Set Style.LevelName to write level names instead of labels.
+	// LeadingAttrs, AttrsOnNewLine, and Style.Lines are ignored.
+	FormatTSV
+)
+
+// appendTSVRecord renders a log record in FormatTSV to bs.
+func (h *Handler) appendTSVRecord(bs []byte, lvl slog.Level, rec slog.Record, view recordView) []byte {
+	bs = appendTSVField(bs, h.timeString(lvl, rec.Time))
+	bs = append(bs, '\t')
+	bs = appendTSVField(bs, h.levelNameString(lvl))
+	bs = append(bs, '\t')
+	bs = appendTSVField(bs, view.prefix)
+	bs = append(bs, '\t')
+	bs = appendTSVField(bs, rec.Message)
+	bs = append(bs, '\t')
+
+	f := h.attrFormatter(nil)
+	valbs := *takeBuf(h.bufPool)
+	defer releaseBuf(h.bufPool, &valbs)
+	for i, a := range h.arrangeAttrs(rec, view) {
+		if i > 0 {
+			bs = append(bs, ' ')
+		}
+		bs = append(bs, a.fullKey()...)
+		bs = append(bs, '=')
+
+		value := a.attr.Value
+		if b, ok := value.Any().(blockValue); ok {
+			value = slog.AnyValue(b.v).Resolve()
+		}
+		valbs = f.appendValue(valbs[:0], value)
+		bs = appendLogfmtValue(bs, string(valbs))
+	}
+
+	return append(bs, '\n')
+}
+
+// tsvEscaper escapes characters that can't appear in TSV fields.
+var tsvEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"\t", `\t`,
+	"\n", `\n`,
+	"\r", `\r`,
+)
+
+func appendTSVField(bs []byte, s string) []byte {
+	return append(bs, tsvEscaper.Replace(s)...)
+}
+
+// appendLogfmtValue appends a value in the attributes field
+// of FormatTSV, quoting it if necessary.
+func appendLogfmtValue(bs []byte, s string) []byte {
+	needsQuote := s == "" || strings.ContainsFunc(s, func(r rune) bool {
+		return r == ' ' || r == '"' || r == '=' || !unicode.IsPrint(r)
+	})
+	if needsQuote {
+		return strconv.AppendQuote(bs, s)
+	}
+	return append(bs, s...)
+}
@@ -0,0 +1,121 @@
+package silog_test
+
+import (
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.abhg.dev/log/silog"
+)
+
+func TestHandler_formatTSV(t *testing.T) {
+	var buffer strings.Builder
+	handler := silog.NewHandler(&buffer, &silog.HandlerOptions{
+		Level:  slog.LevelDebug,
+		Style:  silog.PlainStyle(),
+		Format: silog.FormatTSV,
+		ReplaceAttr: func(groups []string, attr slog.Attr) slog.Attr {
+			if len(groups) == 0 && attr.Key == slog.TimeKey {
+				return slog.Time(slog.TimeKey, time.Date(2025, 1, 2, 15, 4, 5, 0, time.UTC))
+			}
+			if attr.Key == "secret" {
+				return slog.String(attr.Key, "REDACTED")
+			}
+			return attr
+		},
+	})
+
+	log := slog.New(handler)
+	log.Info("hello")
+	log.With(slog.Group("req", "id", 42)).Warn("tab\there\nnewline",
+		"path", `C:\tmp`,
+		"msg", "two words",
+		"empty", "",
+		"eq", "a=b",
+		"err", errors.New("line1\nline2"),
+		"secret", "hunter2",
+		"block", silog.Block("x"),
+	)
+	slog.New(handler.WithPrefix("db")).Debug("prefixed")
+
+	assert.Equal(t,
+		"3:04PM\tINF\t\thello\t\n"+
+			"3:04PM\tWRN\t\ttab\\there\\nnewline\t"+
+			`req.id=42 path=C:\tmp msg="two words" empty="" eq="a=b" `+
+			`err="line1\nline2" secret=REDACTED block=x`+"\n"+
+			"3:04PM\tDBG\tdb\tprefixed\t\n",
+		buffer.String())
+}
+
+func TestHandler_formatTSV_stableColumns(t *testing.T) {
+	var buffer strings.Builder
+	handler := silog.NewHandler(&buffer, &silog.HandlerOptions{
+		Style:       silog.PlainStyle(),
+		Format:      silog.FormatTSV,
+		ReplaceAttr: skipTime,
+	})
+
+	log := slog.New(handler)
+	log.Info("foo")
+	log.Info("bar", "k", "v")
+
+	for line := range strings.Lines(buffer.String()) {
+		assert.Equal(t, 4, strings.Count(line, "\t"), "line: %q", line)
+	}
+	assert.Equal(t, "\tINF\t\tfoo\t\n\tINF\t\tbar\tk=v\n", buffer.String())
+}
+
+func TestHandler_formatTSV_levelName(t *testing.T) {
+	const levelTrace = slog.LevelDebug - 4
+
+	tests := []struct {
+		name      string
+		levelName func(slog.Level) string
+		want      []string
+	}{
+		{
+			name: "Default",
+			want: []string{"DEBUG-4", "INF", "INFO+2"},
+		},
+		{
+			name: "Custom",
+			levelName: func(lvl slog.Level) string {
+				if lvl == levelTrace {
+					return "TRACE"
+				}
+				return lvl.String()
+			},
+			want: []string{"TRACE", "INFO", "INFO+2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			style := silog.PlainStyle()
+			style.LevelName = tt.levelName
+
+			var buffer strings.Builder
+			handler := silog.NewHandler(&buffer, &silog.HandlerOptions{
+				Level:       levelTrace,
+				Style:       style,
+				Format:      silog.FormatTSV,
+				ReplaceAttr: skipTime,
+			})
+
+			log := slog.New(handler)
+			ctx := t.Context()
+			log.Log(ctx, levelTrace, "foo")
+			log.Log(ctx, slog.LevelInfo, "bar")
+			log.Log(ctx, slog.LevelInfo+2, "baz")
+
+			var got []string
+			for line := range strings.Lines(buffer.String()) {
+				got = append(got, strings.Split(line, "\t")[1])
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
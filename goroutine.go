@@ -0,0 +1,33 @@
+package silog
+
+import (
+	"bytes"
+	"runtime"
+	"strconv"
+)
+
+// goroutineID returns the ID of the calling goroutine,
+// or 0 if it cannot be determined.
+//
+// Go does not expose goroutine IDs,
+// so this parses the header of the goroutine's stack trace:
+//
+//	goroutine 42 [running]:
+//
+// This costs a few hundred nanoseconds per call.
+func goroutineID() uint64 {
+	var buf [64]byte
+	bs := buf[:runtime.Stack(buf[:], false)]
+	bs, ok := bytes.CutPrefix(bs, []byte("goroutine "))
+	if !ok {
+		return 0
+	}
+	if i := bytes.IndexByte(bs, ' '); i >= 0 {
+		bs = bs[:i]
+	}
+	id, err := strconv.ParseUint(string(bs), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return id
+}
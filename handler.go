@@ -4,15 +4,104 @@ import (
 	"bytes"
 	"cmp"
 	"context"
+	"encoding"
+	"errors"
+	"fmt"
 	"io"
+	"iter"
 	"log/slog"
+	"maps"
+	"math"
+	"os"
+	"reflect"
 	"slices"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+	"unicode/utf8"
+
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/colorprofile"
+	"github.com/charmbracelet/x/ansi"
 )
 
+// TimeFormatMillis is a time layout for [HandlerOptions.TimeFormat]
+// that renders timestamps with millisecond resolution,
+// for example "15:04:05.123".
+//
+// This is better suited than the default time.Kitchen
+// for correlating events in long-running servers.
+const TimeFormatMillis = "15:04:05.000"
+
+// TimeFormatISO is a time layout for [HandlerOptions.TimeFormat]
+// that renders timestamps in ISO 8601 format
+// with millisecond resolution and the time zone offset,
+// for example "2025-01-02T15:04:05.123-08:00".
+//
+// Unlike time.RFC3339, it always includes subsecond precision.
+const TimeFormatISO = "2006-01-02T15:04:05.000Z07:00"
+
+// ServerOptions returns HandlerOptions suited to long-running servers.
+// Timestamps are rendered with [TimeFormatMillis], and output is unstyled.
+//
+// The returned options may be modified before passing them to [NewHandler].
+func ServerOptions() *HandlerOptions {
+	return &HandlerOptions{
+		Style:      PlainStyle(),
+		TimeFormat: TimeFormatMillis,
+	}
+}
+
+// NewCLILogger returns a logger suited to command line programs
+// that writes to w at or above the given level.
+//
+// It is equivalent to the following:
+//
+//	profile := silog.DetectColorProfile(w, os.Environ())
+//	style := silog.DefaultStyle()
+//	if profile == colorprofile.NoTTY || profile == colorprofile.Ascii {
+//		style = silog.PlainStyle()
+//	}
+//	slog.New(silog.NewHandler(w, &silog.HandlerOptions{
+//		Level:        level,
+//		Style:        style,
+//		ColorProfile: profile,
+//		ReplaceAttr: func(groups []string, attr slog.Attr) slog.Attr {
+//			if len(groups) == 0 && attr.Key == slog.TimeKey {
+//				return slog.Attr{} // omit time
+//			}
+//			return attr
+//		},
+//	}))
+//
+// That is, output is colored only if w is a terminal that supports color
+// or FORCE_COLOR is set, respecting NO_COLOR
+// and similar environment variables (see [DetectColorProfile]),
+// and records are written without timestamps.
+func NewCLILogger(w io.Writer, level slog.Leveler) *slog.Logger {
+	profile := DetectColorProfile(w, os.Environ())
+	style := DefaultStyle()
+	if !hasColor(profile) {
+		style = PlainStyle()
+	}
+	return slog.New(NewHandler(w, &HandlerOptions{
+		Level:        level,
+		Style:        style,
+		ColorProfile: profile,
+		ReplaceAttr:  omitTime,
+	}))
+}
+
+// omitTime is a ReplaceAttr function that drops the time of records.
+func omitTime(groups []string, attr slog.Attr) slog.Attr {
+	if len(groups) == 0 && attr.Key == slog.TimeKey {
+		return slog.Attr{}
+	}
+	return attr
+}
+
 // HandlerOptions defines options for constructing a [Handler].
 type HandlerOptions struct {
 	// Level is the minimum log level to log.
@@ -27,6 +116,9 @@ type HandlerOptions struct {
 
 	// TimeFormat is the format to use when rendering timestamps.
 	// If unset, time.Kitchen will be used.
+	//
+	// Use [TimeFormatMillis] for millisecond-resolution timestamps,
+	// or [TimeFormatISO] for full ISO 8601 timestamps.
 	TimeFormat string // optional
 
 	// ReplaceAttr, if set, is called for each attribute
@@ -36,6 +128,688 @@ type HandlerOptions struct {
 	// respectively.
 	// It is not called if the associated time for the record is zero.
 	ReplaceAttr func(groups []string, attr slog.Attr) slog.Attr // optional
+
+	// DefaultAttrs are attributes included in every log record
+	// written by this handler and handlers derived from it.
+	//
+	// They are always written first, before attributes added with
+	// WithAttrs (e.g. slog.Logger.With) and the record's own attributes.
+	// They are not affected by WithGroup.
+	// ReplaceAttr is called on them once when the handler is constructed.
+	DefaultAttrs []slog.Attr // optional
+
+	// AttrsOnNewLine, if set, writes the attributes of a record
+	// on a new indented line after the message
+	// instead of on the same line as the message.
+	//
+	//	INF Request finished
+	//	  method=GET path=/ status=200
+	AttrsOnNewLine bool // optional
+
+	// GroupAttrsTogether, if set, reorders the attributes of a record
+	// so that attributes in the same group are adjacent.
+	// For example, instead of:
+	//
+	//	a.x=1 b.y=2 a.z=3
+	//
+	// The handler will write:
+	//
+	//	a.x=1 a.z=3 b.y=2
+	//
+	// Groups, and attributes within a group, retain their relative order:
+	// groups are placed where their first attribute appears.
+	//
+	// This requires all attributes of a record,
+	// including those added with WithAttrs,
+	// to be held until the record is written,
+	// which is more expensive than the default behavior.
+	GroupAttrsTogether bool // optional
+
+	// DetailWriter, if set, is an additional destination
+	// for the full rendering of each log record.
+	//
+	// When this is set, the primary writer receives a summary of each record:
+	// its time, level, message,
+	// and only the attributes listed in SummaryKeys.
+	// DetailWriter receives the full record with all attributes.
+	// Both are tagged with a "ref" attribute with the same value
+	// to correlate the two.
+	//
+	//	// primary:
+	//	INF Build failed  ref=3
+	//	// detail:
+	//	INF Build failed  output=
+	//	  | ...
+	//	  ref=3
+	//
+	// Writes to both writers are synchronized together.
+	DetailWriter io.Writer // optional
+
+	// SummaryKeys lists the attributes written to the primary writer
+	// when DetailWriter is set.
+	// Attributes in groups are matched by their full key (e.g. "req.id").
+	//
+	// If empty, attributes are written only to the DetailWriter.
+	SummaryKeys []string // optional
+
+	// LeadingAttrs lists attributes whose values are rendered
+	// between the level and the message, instead of after the message.
+	// Attributes in groups are matched by their full key (e.g. "req.id").
+	// Use this for layouts like HTTP access logs:
+	//
+	//	INF 200 GET /users  duration=3ms
+	//
+	// Only values are rendered, styled with Style.Values.
+	// If multiple leading attributes are present,
+	// they're rendered in the order they're listed in LeadingAttrs.
+	// Attributes with the same key are rendered in the order they were added.
+	//
+	// Attributes with multi-line values are not moved.
+	LeadingAttrs []string // optional
+
+	// DedupGroups, if set, makes WithGroup ignore a group name
+	// that is the same as the innermost group.
+	// For example, with DedupGroups set,
+	// WithGroup("a").WithGroup("a") is the same as WithGroup("a"),
+	// but WithGroup("a").WithGroup("b").WithGroup("a") is unchanged.
+	//
+	// Use this to prevent accidental double-nesting
+	// when multiple layers of code add the same group.
+	DedupGroups bool // optional
+
+	// BufferPool is the pool of buffers used to render log records.
+	// If unset, a package-level pool shared by all handlers is used.
+	// Use [NoBufferPool] to allocate new buffers for every record.
+	BufferPool BufferPool // optional
+
+	// ColorDelimiterWithValue, if set, renders the delimiter
+	// between keys and values (Style.KeyValueDelimiter)
+	// with the foreground color of the value
+	// for attributes that have a style in Style.Values.
+	//
+	// With DefaultStyle, this renders "=" in red for "error" attributes.
+	ColorDelimiterWithValue bool // optional
+
+	// AlignPrefix, if set, pads the prefix of each message
+	// (see [Handler.WithPrefix]) to PrefixWidth.
+	// Messages without a prefix are padded by the same amount,
+	// so messages line up whether or not a prefix is present.
+	//
+	// Prefixes that are wider than PrefixWidth are not padded.
+	AlignPrefix bool // optional
+
+	// PrefixWidth is the display width of the widest prefix
+	// that will be used with this handler.
+	// It is used only if AlignPrefix is set.
+	//
+	// The width is measured on the rendered prefix, ignoring escape codes.
+	PrefixWidth int // optional
+
+	// MaxPrefixLen, if positive, is the maximum length of a prefix
+	// (see [Handler.WithPrefix]) measured in runes.
+	// Longer prefixes are shortened by replacing their middle with "…".
+	// For example, "database" with MaxPrefixLen 5 becomes "da…se".
+	//
+	// The limit does not include Style.PrefixDelimiter or brackets.
+	MaxPrefixLen int // optional
+
+	// ColorProfile is the color profile of the output.
+	// Features that only add color, like HighlightMessagePairs,
+	// are disabled if this is colorprofile.NoTTY or colorprofile.Ascii.
+	// If unset, the output is assumed to support color.
+	//
+	// Styles in Style are rendered as-is regardless of this setting.
+	// Use [PlainStyle] for output without any escape codes.
+	ColorProfile colorprofile.Profile // optional
+
+	// HighlightMessagePairs, if set, styles the keys of
+	// key=value pairs in log messages with Style.Key,
+	// so that they look like attributes.
+	// For example, in "retrying op=fetch attempt=2",
+	// "op" and "attempt" are highlighted.
+	//
+	// Only words made of an identifier (letters, digits, '_', '.', '-'
+	// starting with a letter or '_'), an '=', and a non-empty value
+	// without another '=' are highlighted.
+	// Other text in the message is left untouched.
+	//
+	// This has no effect if ColorProfile does not support color.
+	HighlightMessagePairs bool // optional
+
+	// PreserveTrailingSpace, if set, retains trailing spaces
+	// at the end of messages and attribute values.
+	//
+	// By default, trailing spaces and newlines
+	// at the end of each log record are removed,
+	// so a message "foo " with no attributes is written as "foo".
+	// Trailing spaces elsewhere, e.g. on the first line
+	// of a multi-line message, are always retained.
+	PreserveTrailingSpace bool // optional
+
+	// RenderLevel, if set, renders the level label of each record
+	// in place of Style.LevelLabels.
+	// It receives the level after any level offset,
+	// and the handler's style.
+	// It returns the label with styling applied,
+	// or an empty string to omit the label.
+	//
+	//	RenderLevel: func(lvl slog.Level, _ *silog.Style) string {
+	//		return "[" + lvl.String() + "]"
+	//	},
+	//
+	// If ReplaceAttr is also set, it is called for the level first.
+	// RenderLevel receives the level that ReplaceAttr returns.
+	// It is not called if ReplaceAttr drops the level
+	// or replaces it with a value that is not a slog.Level.
+	RenderLevel func(lvl slog.Level, style *Style) string // optional
+
+	// AnyFormat specifies how structs, maps, slices, and arrays
+	// are rendered if they don't have a more specific representation.
+	// See the Handler documentation for the order of precedence.
+	//
+	// Defaults to AnyFormatGoInline.
+	AnyFormat AnyFormat // optional
+
+	// LevelWriters sends records at or above certain levels
+	// to writers other than the one passed to NewHandler.
+	// Each record is written to the writer with the highest level
+	// that is at or below the record's level (after any level offset).
+	// Records below all levels in the map
+	// are written to the writer passed to NewHandler.
+	//
+	// For example, the following sends warnings and errors to stderr,
+	// and everything else to stdout:
+	//
+	//	silog.NewHandler(os.Stdout, &silog.HandlerOptions{
+	//		LevelWriters: map[slog.Level]io.Writer{
+	//			slog.LevelWarn: os.Stderr,
+	//		},
+	//	})
+	//
+	// [NewStdioHandler] provides this setup.
+	//
+	// Writes to each distinct writer are synchronized separately.
+	// Records written to different writers that end up
+	// in the same place (e.g. a terminal) may interleave
+	// out of order.
+	//
+	// Nil writers in the map are ignored.
+	LevelWriters map[slog.Level]io.Writer // optional
+
+	// StripIncomingANSI, if set, removes escape sequences
+	// that are already present in messages and attribute values
+	// (e.g. output captured from a colored subprocess)
+	// when ColorProfile does not support color.
+	// This keeps plain output, like log files, free of escape codes.
+	//
+	// Escape sequences are passed through as-is
+	// if this is unset or the output supports color.
+	StripIncomingANSI bool // optional
+
+	// FlattenKeys, if set, renders only the key of each attribute,
+	// without the names of the groups it's in.
+	// For example, "id" instead of "req.user.id".
+	//
+	// Groups still apply otherwise:
+	// ReplaceAttr receives them as usual.
+	// Attributes in different groups with the same key
+	// are all rendered.
+	FlattenKeys bool // optional
+
+	// RespectContextCancellation, if set, makes Handle drop records
+	// if the context passed to it has already been canceled,
+	// returning the context's error instead of writing the record.
+	//
+	// Use this to avoid blocking on a slow or stalled writer
+	// during shutdown.
+	// Note that this drops log records that would otherwise be written,
+	// which is why it's off by default.
+	RespectContextCancellation bool // optional
+
+	// CollectStats, if set, makes the handler collect statistics
+	// about the records it writes.
+	// Retrieve them with [Handler.Stats].
+	CollectStats bool // optional
+
+	// NeutralMultilinePrefix, if set, renders the prefix
+	// of multi-line attribute values (Style.MultilineValuePrefix) as-is.
+	//
+	// By default, the prefix takes the foreground color
+	// of attributes that have a style in Style.Values.
+	// For example, with DefaultStyle, the prefix is red
+	// for multi-line "error" attributes.
+	NeutralMultilinePrefix bool // optional
+
+	// Format is the output format.
+	// Defaults to FormatText.
+	// See [FormatTSV] for a tab-separated format.
+	Format Format // optional
+
+	// PrefixFromContext, if set, is called with the context
+	// of each log record to get a prefix for that record.
+	// If it returns a non-empty string,
+	// that is used instead of the handler's prefix
+	// (see [Handler.WithPrefix]).
+	//
+	// Use this to derive prefixes from request-scoped values
+	// without building a new handler for each request.
+	PrefixFromContext func(ctx context.Context) string // optional
+
+	// GroupDigits, if set, separates groups of thousands
+	// in integer and floating point attribute values
+	// with DigitSeparator.
+	// For example, 1048576 is rendered as "1,048,576".
+	// Only the integer part of floating point numbers is grouped.
+	//
+	// This is off by default because values with separators
+	// cannot be parsed as numbers by logfmt tools.
+	GroupDigits bool // optional
+
+	// DigitSeparator is the separator used by GroupDigits.
+	// Defaults to ",".
+	DigitSeparator string // optional
+
+	// ElideRepeatedAttrs, if set, replaces the value of an attribute
+	// with RepeatedAttrMarker if the previous record
+	// had an attribute with the same key and value.
+	// For example:
+	//
+	//	INF request started  trace_id=4bf92f3577b34da6
+	//	INF cache miss  trace_id=↑
+	//	INF request done  trace_id=↑ status=200
+	//
+	// Attributes are matched by their full key (e.g. "req.id").
+	// Records are rendered one at a time while this is set
+	// so that the "previous record" is well-defined
+	// across handlers derived from this one.
+	//
+	// The output can no longer be parsed as logfmt
+	// because values are omitted.
+	// Records written to DetailWriter
+	// and records in FormatTSV are not affected.
+	ElideRepeatedAttrs bool // optional
+
+	// RepeatedAttrMarker replaces repeated values
+	// when ElideRepeatedAttrs is set.
+	// Defaults to "↑".
+	RepeatedAttrMarker string // optional
+
+	// MessageWidth, if positive, pads messages with spaces
+	// to this display width, so that attributes that follow them
+	// start at the same column:
+	//
+	//	INF started         port=8080
+	//	INF connected       db=main
+	//
+	// The width includes the prefix, if any,
+	// and is measured ignoring escape codes.
+	// For multi-line messages, only the last line is padded.
+	// Messages wider than this are not padded.
+	//
+	// This has no effect if AttrsOnNewLine is set.
+	MessageWidth int // optional
+
+	// CountLevels, if set, makes the handler count
+	// the records it writes at each level.
+	// Retrieve the counts with [Handler.Counts],
+	// or report them with [Handler.WriteSummary].
+	CountLevels bool // optional
+
+	// WriteTimeout, if positive, bounds how long
+	// writing a record to the output may block.
+	// If a write does not complete in time,
+	// Handle returns an error that matches context.DeadlineExceeded.
+	//
+	// Use this to prevent a stalled output (e.g. a full pipe)
+	// from blocking the application.
+	//
+	// Writes that time out continue in the background,
+	// and records logged while they're in progress may be dropped.
+	// Banner, FileHandler.Rotate, and FileHandler.Close
+	// wait for such writes to finish.
+	// If a write times out, the state of the output is undefined:
+	// the record may have been partially written, or not at all.
+	WriteTimeout time.Duration // optional
+
+	// GroupBraces, if set, writes the group path of attributes once,
+	// followed by its members enclosed in braces.
+	// For example, instead of:
+	//
+	//	request.method=POST request.path=/x
+	//
+	// The handler will write:
+	//
+	//	request{method=POST path=/x}
+	//
+	// Multi-line members are written outside the braces
+	// with their full group path.
+	//
+	// Combine with GroupAttrsTogether to brace
+	// all attributes of a group together.
+	// This has no effect if FlattenKeys is set.
+	GroupBraces bool // optional
+
+	// IndentContinuationLines, if set, writes the time, level,
+	// and prefix only on the first line of multi-line messages.
+	// Other lines are indented to align with the first line's message:
+	//
+	//	ERR panic: something went wrong
+	//	    goroutine 1 [running]:
+	//	    main.main()
+	//
+	// By default, every line of the message is written
+	// with the time, level, and prefix
+	// so that each line can be found with grep.
+	IndentContinuationLines bool // optional
+
+	// SanitizeUTF8, if set, replaces invalid UTF-8 sequences
+	// in messages and attribute values with the Unicode replacement
+	// character (U+FFFD) before they're written.
+	//
+	// Use this when logging data from untrusted or binary sources
+	// to keep garbage bytes out of the terminal.
+	SanitizeUTF8 bool // optional
+
+	// ElideRepeatedTime, if set, replaces the time of a record
+	// with spaces if it's the same as the time of the previous record
+	// after formatting with TimeFormat.
+	// For example:
+	//
+	//	9:45AM INF request started
+	//	       INF cache miss
+	//	9:46AM INF request done
+	//
+	// Like ElideRepeatedAttrs, records are rendered one at a time
+	// while this is set.
+	// Records written to DetailWriter
+	// and records in FormatTSV are not affected.
+	ElideRepeatedTime bool // optional
+
+	// AttrPriority lists attributes that are written first,
+	// right after the message, so that they stay visible
+	// when long lines are cut off.
+	// Attributes in groups are matched by their full key (e.g. "req.id").
+	//
+	// Listed attributes are written in the order they're listed here,
+	// followed by all other attributes in their original order.
+	// This takes precedence over GroupAttrsTogether:
+	// attributes are clustered by group first, and then prioritized.
+	//
+	// Unlike LeadingAttrs, attributes stay after the message.
+	AttrPriority []string // optional
+
+	// BaseIndent, if set, is written at the start of every line
+	// of output, including lines of multi-line messages and values.
+	// Use this to nest log output under other output, e.g. a heading.
+	//
+	// This has no effect on FormatTSV.
+	BaseIndent string // optional
+
+	// VerboseFormat, if set, renders values that implement fmt.Formatter
+	// with the %+v verb instead of %v.
+	// Use this with error types that include stack traces in %+v.
+	VerboseFormat bool // optional
+
+	// LevelTimeFormats specifies time layouts for records
+	// at specific levels (after any level offset),
+	// e.g. to use high-precision timestamps only for debug logs:
+	//
+	//	silog.NewHandler(w, &silog.HandlerOptions{
+	//		LevelTimeFormats: map[slog.Level]string{
+	//			slog.LevelDebug: silog.TimeFormatMillis,
+	//		},
+	//	})
+	//
+	// Records at levels not in the map use TimeFormat.
+	// The layout also applies to times returned by ReplaceAttr
+	// for the time attribute.
+	LevelTimeFormats map[slog.Level]string // optional
+
+	// ContextPrefix specifies how prefixes added to the context
+	// of a record with [ContextWithPrefix] are used.
+	// The prefixes are joined with PrefixSeparator.
+	//
+	// With ContextPrefixReplace, prefixes in the context
+	// replace the handler's prefix (see [Handler.WithPrefix]).
+	// With ContextPrefixAppend, they're appended to it.
+	// In both cases, records logged with a context without prefixes
+	// use the handler's prefix.
+	//
+	// If PrefixFromContext is also set,
+	// its result is used as the handler's prefix.
+	//
+	// Defaults to ContextPrefixIgnore.
+	ContextPrefix ContextPrefix // optional
+
+	// PrefixSeparator joins prefixes added with [ContextWithPrefix]
+	// to each other and to the handler's prefix.
+	//
+	// Defaults to "/".
+	PrefixSeparator string // optional
+
+	// ShowGoroutineID, if set, adds the ID of the goroutine
+	// that logged each record to every line of the record
+	// after the level, e.g. "INF go42 message".
+	// Use this to correlate records when debugging concurrency.
+	//
+	// Go does not provide an API for goroutine IDs,
+	// so the handler parses it from the goroutine's stack trace.
+	// This makes each log call noticeably slower,
+	// so this is meant for debugging only.
+	// Goroutine IDs are not shown with FormatTSV.
+	ShowGoroutineID bool // optional
+
+	// PromoteErrorFields, if set, extracts key=value pairs
+	// at the end of the messages of errors in error attributes
+	// (see Style.ErrorKeys) into separate attributes.
+	// For example, with this set,
+	//
+	//	slog.Any("error", errors.New("write failed op=write path=/x"))
+	//
+	// is rendered as if it were the following attributes,
+	// so that op and path are styled like other attributes:
+	//
+	//	slog.String("error", "write failed"),
+	//	slog.String("op", "write"),
+	//	slog.String("path", "/x"),
+	//
+	// Use this for errors from libraries that don't log structured data.
+	//
+	// This is heuristic and conservative:
+	// pairs are only extracted if keys are identifiers
+	// (as defined for HighlightMessagePairs)
+	// and values don't contain quotes,
+	// and never from multi-line messages.
+	// The promoted attributes are not passed to ReplaceAttr.
+	PromoteErrorFields bool // optional
+
+	// DurationFormat specifies how time.Duration values are rendered.
+	//
+	// Defaults to DurationFormatGo.
+	DurationFormat DurationFormat // optional
+
+	// InlineValueMaxLen, if positive, is the display width
+	// of the longest attribute value rendered inline.
+	// Longer values are rendered on their own lines
+	// like multi-line values (see [Block]),
+	// so that the other attributes remain easy to scan.
+	InlineValueMaxLen int // optional
+
+	// Now, if set, is the clock used for the time of records
+	// that don't have one.
+	// Use this for deterministic output in tests,
+	// or to log with virtual time in simulations.
+	//
+	// slog.Logger sets the time of each record to time.Now,
+	// so Now only applies to records built without a time
+	// (e.g. with slog.NewRecord and a zero time)
+	// unless ForceClock is also set.
+	Now func() time.Time // optional
+
+	// ForceClock, if set, uses Now for the time of all records,
+	// replacing times set by slog.Logger.
+	// It has no effect if Now is unset.
+	ForceClock bool // optional
+
+	// GroupDepthThreshold, if positive, is the number of groups
+	// an attribute may be nested in and still be rendered
+	// with a dotted key (e.g. "a.b.key=value").
+	// Attributes nested more deeply are rendered on their own lines
+	// as an indented tree, with the first GroupDepthThreshold groups
+	// dotted at its root.
+	// For example, with a threshold of 2,
+	// attributes in the groups "a", "b", "c", and "d" are rendered as:
+	//
+	//	a.b:
+	//	  c:
+	//	    d:
+	//	      key=value
+	//	      other=value
+	//
+	// Consecutive attributes in the same groups share their branches.
+	// Combine with GroupAttrsTogether to keep all members
+	// of a group in the same tree.
+	// This has no effect if FlattenKeys is set,
+	// and takes precedence over GroupBraces.
+	GroupDepthThreshold int // optional
+
+	// VerboseAttrs, if set, writes each attribute of a record
+	// on its own indented line after the message,
+	// with keys (including their groups) right-aligned:
+	//
+	//	INF Request finished
+	//	       method=GET
+	//	         path=/
+	//	  req.headers=
+	//	      | Accept: */*
+	//	      | Host: example.com
+	//
+	// Multi-line values are written below their keys
+	// with an extra level of indentation.
+	// GroupBraces and GroupDepthThreshold are ignored if this is set.
+	VerboseAttrs bool // optional
+
+	// MetadataColumnWidth, if positive, renders the metadata
+	// of each line of a record (time, level, and prefix)
+	// in a column of this display width,
+	// separated from the message by Style.ColumnRule:
+	//
+	//	9:45AM INF db: │ Connected
+	//	9:45AM WRN     │ Retrying
+	//
+	// Metadata wider than the column is not truncated.
+	// If ColorProfile is colorprofile.NoTTY or colorprofile.Ascii,
+	// a space is used in place of the rule.
+	// This has no effect with FormatTSV.
+	MetadataColumnWidth int // optional
+
+	// ColorKeyWithValue, if set, renders the keys
+	// of attributes that have a style in Style.Values
+	// with the foreground color of the value.
+	//
+	// The key, delimiter, and value of a styled attribute
+	// are rendered the same way whether the value
+	// fits on one line or spans multiple lines.
+	// See also ColorDelimiterWithValue and NeutralMultilinePrefix.
+	ColorKeyWithValue bool // optional
+
+	// SingleLineMessages, if set, escapes newlines in messages
+	// as "\n" (and carriage returns as "\r")
+	// so that each message is rendered on a single line.
+	//
+	// By default, each line of a multi-line message
+	// is written with the time and level of the record.
+	// This does not affect multi-line attribute values,
+	// which are always rendered with Style.MultilineValuePrefix.
+	SingleLineMessages bool // optional
+
+	// MessageCase changes the case of the first letter of messages
+	// for consistency across authors,
+	// e.g. MessageCaseLowerFirst to follow Go conventions.
+	// Defaults to MessageCaseAsIs.
+	MessageCase MessageCase // optional
+
+	// ScalarSliceJoin, if set, renders slices and arrays
+	// of bools, strings, and numbers
+	// as their elements joined by this separator.
+	// For example, with ScalarSliceJoin set to ",":
+	//
+	//	tags=a,b,c
+	//
+	// Elements are quoted if they are empty,
+	// or contain the separator, spaces, or quotes.
+	// Empty slices are rendered as "[]".
+	// Slices of other types are not affected.
+	ScalarSliceJoin string // optional
+
+	// MaxAttrs, if positive, is the maximum number of attributes
+	// rendered for each record.
+	// Attributes past this limit are replaced with a marker
+	// that reports how many were omitted (Style.MoreAttrs):
+	//
+	//	INF request  method=GET path=/ …(+3 more)
+	//
+	// Attributes are counted after grouping and ordering,
+	// so attributes in LeadingAttrs count first,
+	// followed by those listed in AttrPriority.
+	// Attributes inside groups count individually,
+	// and multi-line attributes count as one.
+	// This has no effect with FormatTSV.
+	MaxAttrs int // optional
+
+	// ClampLevelOffset, if set, stops [Handler.WithLevelOffset]
+	// from moving levels past the lowest and highest levels
+	// in Style.LevelLabels, which would leave them unlabeled.
+	// For example, with DefaultStyle and an offset of -12,
+	// slog.LevelError becomes slog.LevelDebug
+	// instead of slog.LevelDebug-8.
+	//
+	// Levels that are already outside that range are not moved further.
+	ClampLevelOffset bool // optional
+
+	// LevelHook, if its Func is set, is called
+	// after records at or above its Level are written.
+	// See [LevelHook] for details.
+	LevelHook LevelHook // optional
+
+	// ErrorFallbackWriter, if set, receives a short diagnostic line
+	// for each record that could not be written to the output,
+	// e.g. because the output was closed or a write timed out:
+	//
+	//	silog: dropped ERROR record "connection lost": write /var/log/app.log: file already closed
+	//
+	// The diagnostic includes the level of the record,
+	// its message truncated to 60 characters, and the error.
+	// Errors from this writer are ignored.
+	// Diagnostics are written one at a time.
+	// If this writer logs to the same handler and that fails,
+	// the diagnostic for the nested failure is dropped.
+	//
+	// Typically, this is os.Stderr.
+	ErrorFallbackWriter io.Writer // optional
+
+	// MultilineMarker, if set, writes Style.MultilineMarker
+	// after the message of records that have multi-line attributes
+	// to make them easier to spot:
+	//
+	//	INF Request failed ⤷
+	//	  error=
+	//	    | connection reset
+	//	    | retrying
+	//
+	// This has no effect with FormatTSV.
+	MultilineMarker bool // optional
+
+	// ShowTimeDelta, if set, writes the time since the previous record
+	// after the time of each record, styled with Style.TimeDelta:
+	//
+	//	9:45:01.120 (+12ms) INF Query done
+	//
+	// The delta is rendered like DurationFormatAdaptive.
+	// The first record, and records without a time, have no delta.
+	// Handlers derived from the same Handler share the previous record.
+	// This has no effect with FormatTSV.
+	ShowTimeDelta bool // optional
 }
 
 // Handler is a slog.Handler that writes to an io.Writer
@@ -46,12 +820,42 @@ type HandlerOptions struct {
 //
 //   - rendering of trace level
 //   - multi-line fields are indented and aligned
+//
+// # Attribute values
+//
+// Attribute values that are not one of the basic slog kinds
+// (string, int, bool, time, etc.) are rendered using the first of the
+// following that applies:
+//
+//   - slog.LogValuer: the value is resolved first,
+//     and the resolved value is rendered with these rules
+//   - []slog.Attr: the value is rendered as a group
+//     with the attribute's key as the group name
+//   - nil values, including typed nil pointers, maps, and slices,
+//     are rendered as Style.NullValue
+//   - pointers to basic types (e.g. *bool, *int) and the Null types
+//     of database/sql (e.g. sql.NullString, sql.Null[T]):
+//     the wrapped value, or Style.NullValue if it's absent
+//   - slog.Source and *slog.Source: the file and line as "file:line"
+//   - fmt.Formatter: the Format method with the %v verb,
+//     or %+v if HandlerOptions.VerboseFormat is set
+//   - fmt.Stringer or error: the String or Error method
+//   - encoding.TextMarshaler: the output of MarshalText,
+//     unless it fails
+//   - with HandlerOptions.ScalarSliceJoin, slices and arrays
+//     of bools, strings, and numbers are rendered as their elements
+//     joined by the separator
+//   - with [AnyFormatJSON], structs, maps, slices, and arrays
+//     are rendered as JSON, unless encoding fails
+//   - the default fmt formatting of the value (%v)
 type Handler struct {
 	lvl   slog.Leveler // required
 	style *Style       // required
 	outMu *sync.Mutex  // required
 	out   io.Writer    // required
 
+	bufPool BufferPool // required
+
 	// attrs holds attributes that have already been serialized
 	// with WithAttrs.
 	//
@@ -59,25 +863,262 @@ type Handler struct {
 	// and not modified afterwards.
 	attrs []byte
 
+	// attrsMultiline is set if attrs includes multi-line attributes.
+	attrsMultiline bool
+
+	// deferAttrs is set if attributes cannot be serialized
+	// until all attributes for a record are known.
+	// If set, groupedAttrs is used instead of attrs.
+	deferAttrs bool
+
+	// groupedAttrs holds attributes added with WithAttrs
+	// when deferAttrs is set.
+	// These have already been resolved and passed through ReplaceAttr.
+	//
+	// Like attrs, this is not modified after construction.
+	groupedAttrs []groupedAttr
+
+	// groupAttrs clusters attributes of the same group together.
+	groupAttrs bool
+
+	// detailOut, if set, receives full records,
+	// while out receives summaries with only summaryKeys.
+	detailOut   io.Writer
+	detailSeq   *atomic.Uint64 // shared between derived handlers
+	summaryKeys []string
+
+	// leadingAttrs lists keys of attributes
+	// rendered before the message.
+	leadingAttrs []string
+
+	// attrPriority lists keys of attributes
+	// rendered before other attributes.
+	attrPriority []string
+
+	// baseIndent is written at the start of every line.
+	baseIndent string
+
 	// groups is the current group stack.
 	groups []string
 
+	// replaceGroups precede groups in the group path
+	// passed to ReplaceAttr for attributes.
+	// TreeHandler uses this for groups that it renders as branches
+	// instead of qualifying keys with them.
+	replaceGroups []string
+
 	// Number of levels to downgrade a log message
 	// before writing it.
 	lvlOffset int
 
+	// clampOffset limits the level offset
+	// to the range [lvlMin, lvlMax] of labeled levels.
+	clampOffset    bool
+	lvlMin, lvlMax slog.Level
+
+	// hook is called after matching records are written, if set.
+	hook *levelHook
+
+	// fallback reports records that failed to write, if set.
+	fallback *errorFallback
+
+	// multilineMarker follows the message of records
+	// with multi-line attributes if non-empty.
+	multilineMarker string
+
+	// discard is set for handlers that never log anything.
+	discard bool
+
+	// lvlFunc, if set, overrides lvl for deciding
+	// whether a record should be logged.
+	lvlFunc func(context.Context, slog.Level) bool
+
 	// prefix is the prefix to use for the logger.
 	prefix string
 
+	// colorDelimiter colors the key-value delimiter like styled values.
+	colorDelimiter bool
+
+	// neutralPrefix doesn't color multi-line prefixes like styled values.
+	neutralPrefix bool
+
+	// colorKey colors keys like styled values.
+	colorKey bool
+
+	// anyFormat is the format for composite values.
+	anyFormat AnyFormat
+
+	// verbose formats fmt.Formatter values with %+v.
+	verbose bool
+
+	// levelOuts are writers for records at or above certain levels,
+	// sorted by level in descending order.
+	// If a record is below all levels, it's written to out.
+	levelOuts []levelOutput
+
+	// renderLevel, if set, renders level labels.
+	renderLevel func(slog.Level, *Style) string
+
+	// profile is the color profile of the output.
+	profile colorprofile.Profile
+
+	// stripANSI removes escape sequences from messages and values.
+	// This is unset if the output supports color.
+	stripANSI bool
+
+	// sanitizeUTF8 replaces invalid UTF-8 in messages and values.
+	sanitizeUTF8 bool
+
+	// singleLineMsg escapes newlines in messages.
+	singleLineMsg bool
+
+	// msgCase changes the case of the first letter of messages.
+	msgCase MessageCase
+
+	// sliceJoin joins the elements of scalar slices if non-empty.
+	sliceJoin string
+
+	// maxAttrs is the maximum number of attributes per record,
+	// or 0 if unlimited.
+	maxAttrs int
+
+	// boolGlyphs renders booleans with Style.BoolGlyphs.
+	boolGlyphs bool
+
+	// flattenKeys omits group names from keys.
+	flattenKeys bool
+
+	// respectCtx drops records if the context is canceled.
+	respectCtx bool
+
+	// format is the output format.
+	format Format
+
+	// showGoroutine adds goroutine IDs to records.
+	showGoroutine bool
+
+	// promoteErrs extracts fields from error messages.
+	promoteErrs bool
+
+	// durationFormat is the format for time.Duration values.
+	durationFormat DurationFormat
+
+	// inlineMaxLen is the maximum width of inline values.
+	inlineMaxLen int
+
+	// now, if set, provides times for records.
+	// forceClock uses it even if records have a time.
+	now        func() time.Time
+	forceClock bool
+
+	// prefixFromCtx, if set, gets per-record prefixes.
+	prefixFromCtx func(context.Context) string
+
+	// ctxPrefix specifies how to use prefixes from ContextWithPrefix,
+	// and prefixSep joins them.
+	ctxPrefix ContextPrefix
+	prefixSep string
+
+	// digitSep separates groups of digits in numbers.
+	// This is empty if digits are not grouped.
+	digitSep string
+
+	// msgWidth is the width to pad messages to.
+	// This is zero if messages are not padded.
+	msgWidth int
+
+	// repeats tracks values of the previous record
+	// if repeated attributes are elided.
+	repeats *repeatCache // shared between derived handlers
+
+	// lastTime tracks the time of the previous record
+	// if repeated times are elided.
+	lastTime *timeCache // shared between derived handlers
+
+	// deltas tracks the time of the previous record
+	// if time deltas are shown.
+	deltas *deltaClock // shared between derived handlers
+
+	// stats collects statistics if non-nil.
+	stats *handlerStats // shared between derived handlers
+
+	// counts counts records per level if non-nil.
+	counts *levelCounts // shared between derived handlers
+
+	// writeTimeout bounds how long writes may block.
+	// This is zero if writes are not bounded.
+	writeTimeout time.Duration
+
+	// groupBraces encloses members of groups in braces.
+	groupBraces bool
+
+	// groupDepth is the maximum number of groups
+	// rendered in dotted keys, or zero for no limit.
+	groupDepth int
+
+	// indentCont writes the time, level, and prefix
+	// only on the first line of multi-line messages.
+	indentCont bool
+
+	// writeSem is held while a write with a timeout is in progress.
+	// It prevents concurrent writes with one that timed out.
+	writeSem chan struct{} // shared between derived handlers
+
+	// preserveSpace retains trailing spaces at the end of records.
+	preserveSpace bool
+
+	// highlightPairs highlights key=value pairs in messages.
+	// This is unset if the output does not support color.
+	highlightPairs bool
+
+	// dedupGroups skips WithGroup calls that repeat the innermost group.
+	dedupGroups bool
+
+	// attrsOnNewLine writes attributes on a new line after the message.
+	attrsOnNewLine bool
+
+	// verboseAttrs writes each attribute on its own line
+	// with aligned keys.
+	verboseAttrs bool
+
+	// metaWidth, if positive, is the width of the metadata column,
+	// and columnRule separates it from the message.
+	metaWidth  int
+	columnRule string
+
+	// maxPrefixLen is the maximum length of a prefix in runes.
+	// This is zero if prefixes are not shortened.
+	maxPrefixLen int
+
+	// prefixWidth is the width to pad prefixes to.
+	// This is zero if prefixes are not aligned.
+	prefixWidth int
+
 	// timeFormat is the format to use when rendering timestamps.
 	timeFormat string
 
+	// levelTimeFormats overrides timeFormat for specific levels.
+	levelTimeFormats map[slog.Level]string
+
 	// replaceAttr is the attribute replacement function.
 	replaceAttr func([]string, slog.Attr) slog.Attr
 }
 
 var _ slog.Handler = (*Handler)(nil)
 
+// LevelWriter is an io.Writer that can receive
+// the level of the log record being written.
+//
+// If the output writer of a [Handler] implements LevelWriter,
+// the Handler will call WriteLevel instead of Write,
+// passing the level of the record after any level offset.
+type LevelWriter interface {
+	io.Writer
+
+	// WriteLevel writes a log record at the given level.
+	WriteLevel(lvl slog.Level, p []byte) (int, error)
+}
+
 // NewHandler constructs a silog Handler for use with slog.
 // Log output is written to the given io.Writer.
 //
@@ -95,27 +1136,197 @@ func NewHandler(w io.Writer, opts *HandlerOptions) *Handler {
 		lvl = slog.LevelInfo // default level
 	}
 
-	return &Handler{
+	var prefixWidth int
+	if opts.AlignPrefix {
+		prefixWidth = opts.PrefixWidth
+	}
+
+	h := &Handler{
 		lvl:         lvl,
 		style:       style,
 		out:         w,
 		outMu:       new(sync.Mutex),
+		bufPool:     cmp.Or(opts.BufferPool, BufferPool(syncBufferPool{})),
 		timeFormat:  timeFormat,
 		replaceAttr: opts.ReplaceAttr,
-	}
-}
-
-// Enabled reports whether the handler is enabled for the given level.
-//
-// If Enabled returnsf alse, Handle should not be called for a record
-// at that level.
-func (h *Handler) Enabled(_ context.Context, lvl slog.Level) bool {
-	lvl += slog.Level(h.lvlOffset)
-	return h.lvl.Level() <= lvl
-}
+		prefixWidth: prefixWidth,
 
-const (
-	timeDelim    = " "  // separator between time and level
+		maxPrefixLen:   max(opts.MaxPrefixLen, 0),
+		attrsOnNewLine: opts.AttrsOnNewLine,
+		verboseAttrs:   opts.VerboseAttrs,
+		dedupGroups:    opts.DedupGroups,
+		colorDelimiter: opts.ColorDelimiterWithValue,
+		neutralPrefix:  opts.NeutralMultilinePrefix,
+		colorKey:       opts.ColorKeyWithValue,
+		format:         opts.Format,
+		prefixFromCtx:  opts.PrefixFromContext,
+		msgWidth:       max(opts.MessageWidth, 0),
+		highlightPairs: opts.HighlightMessagePairs && hasColor(opts.ColorProfile),
+		preserveSpace:  opts.PreserveTrailingSpace,
+		stripANSI:      opts.StripIncomingANSI && !hasColor(opts.ColorProfile),
+		sanitizeUTF8:   opts.SanitizeUTF8,
+		singleLineMsg:  opts.SingleLineMessages,
+		msgCase:        opts.MessageCase,
+		sliceJoin:      opts.ScalarSliceJoin,
+		maxAttrs:       max(opts.MaxAttrs, 0),
+		profile:        opts.ColorProfile,
+		baseIndent:     opts.BaseIndent,
+		flattenKeys:    opts.FlattenKeys,
+		respectCtx:     opts.RespectContextCancellation,
+		renderLevel:    opts.RenderLevel,
+		anyFormat:      opts.AnyFormat,
+		verbose:        opts.VerboseFormat,
+		groupAttrs:     opts.GroupAttrsTogether,
+		groupBraces:    opts.GroupBraces && !opts.FlattenKeys && !opts.VerboseAttrs,
+		groupDepth:     max(opts.GroupDepthThreshold, 0),
+		indentCont:     opts.IndentContinuationLines,
+		detailOut:      opts.DetailWriter,
+		detailSeq:      new(atomic.Uint64),
+		summaryKeys:    slices.Clone(opts.SummaryKeys),
+		leadingAttrs:   slices.Clone(opts.LeadingAttrs),
+		attrPriority:   slices.Clone(opts.AttrPriority),
+		ctxPrefix:      opts.ContextPrefix,
+		showGoroutine:  opts.ShowGoroutineID,
+		promoteErrs:    opts.PromoteErrorFields,
+		durationFormat: opts.DurationFormat,
+		inlineMaxLen:   opts.InlineValueMaxLen,
+		now:            opts.Now,
+		forceClock:     opts.ForceClock,
+		prefixSep:      cmp.Or(opts.PrefixSeparator, defaultPrefixSeparator),
+	}
+	if opts.ElideRepeatedAttrs {
+		h.repeats = newRepeatCache(cmp.Or(opts.RepeatedAttrMarker, defaultRepeatedAttrMarker))
+	}
+	if hasColor(opts.ColorProfile) && opts.Format != FormatTSV {
+		h.boolGlyphs = style.BoolGlyphs.True.Render() != "" &&
+			style.BoolGlyphs.False.Render() != ""
+	}
+	if len(opts.LevelTimeFormats) > 0 {
+		h.levelTimeFormats = maps.Clone(opts.LevelTimeFormats)
+	}
+	if opts.MultilineMarker {
+		h.multilineMarker = cmp.Or(style.MultilineMarker.Render(), "⤷")
+	}
+	if opts.ErrorFallbackWriter != nil {
+		h.fallback = &errorFallback{w: opts.ErrorFallbackWriter}
+	}
+	if opts.LevelHook.Func != nil {
+		h.hook = &levelHook{LevelHook: opts.LevelHook}
+	}
+	if opts.ClampLevelOffset && len(style.LevelLabels) > 0 {
+		h.clampOffset = true
+		h.lvlMin = slices.Min(slices.Collect(maps.Keys(style.LevelLabels)))
+		h.lvlMax = slices.Max(slices.Collect(maps.Keys(style.LevelLabels)))
+	}
+	if opts.MetadataColumnWidth > 0 {
+		h.metaWidth = opts.MetadataColumnWidth
+		h.columnRule = cmp.Or(style.ColumnRule.Render(), "│")
+		if !hasColor(opts.ColorProfile) {
+			h.columnRule = " "
+		}
+	}
+	if opts.ElideRepeatedTime {
+		h.lastTime = new(timeCache)
+	}
+	if opts.ShowTimeDelta && opts.Format != FormatTSV {
+		h.deltas = new(deltaClock)
+	}
+	if opts.GroupDigits {
+		h.digitSep = cmp.Or(opts.DigitSeparator, ",")
+	}
+	h.levelOuts = newLevelOutputs(w, h.outMu, opts.LevelWriters)
+	if opts.CollectStats {
+		h.stats = new(handlerStats)
+	}
+	if opts.CountLevels {
+		h.counts = new(levelCounts)
+	}
+	if opts.WriteTimeout > 0 {
+		h.writeTimeout = opts.WriteTimeout
+		h.writeSem = make(chan struct{}, 1)
+	}
+	h.deferAttrs = h.groupAttrs ||
+		h.detailOut != nil ||
+		len(h.leadingAttrs) > 0 ||
+		len(h.attrPriority) > 0 ||
+		h.format == FormatTSV ||
+		h.repeats != nil ||
+		h.groupBraces ||
+		h.groupDepth > 0 ||
+		h.verboseAttrs ||
+		h.maxAttrs > 0 ||
+		len(style.ValuesByLevel) > 0
+
+	if len(opts.DefaultAttrs) > 0 {
+		// Default attributes are serialized once
+		// and form the base for all WithAttrs calls.
+		h.addAttrs(opts.DefaultAttrs)
+	}
+
+	return h
+}
+
+// NewStdioHandler constructs a Handler that writes
+// warnings and errors to os.Stderr, and other records to os.Stdout.
+// It is a shorthand for:
+//
+//	silog.NewHandler(os.Stdout, &silog.HandlerOptions{
+//		LevelWriters: map[slog.Level]io.Writer{
+//			slog.LevelWarn: os.Stderr,
+//		},
+//		// ...
+//	})
+//
+// opts.LevelWriters, if set, takes precedence over this split.
+func NewStdioHandler(opts *HandlerOptions) *Handler {
+	newOpts := *cmp.Or(opts, &HandlerOptions{})
+	if newOpts.LevelWriters == nil {
+		newOpts.LevelWriters = map[slog.Level]io.Writer{
+			slog.LevelWarn: os.Stderr,
+		}
+	}
+	return NewHandler(os.Stdout, &newOpts)
+}
+
+// DiscardHandler returns a Handler that discards all log records.
+//
+// Its Enabled method always reports false,
+// so slog.Logger skips building records entirely.
+// It ignores level, style, and all other configuration,
+// including that of handlers derived from it
+// with methods like WithLevel and WithLevelFunc.
+//
+// Use this to disable logging cheaply
+// while still providing a *Handler to code that expects one.
+func DiscardHandler() *Handler {
+	return &Handler{
+		lvl:     slog.LevelInfo,
+		style:   PlainStyle(),
+		out:     io.Discard,
+		outMu:   new(sync.Mutex),
+		bufPool: syncBufferPool{},
+		discard: true,
+	}
+}
+
+// Enabled reports whether the handler is enabled for the given level.
+//
+// If Enabled returnsf alse, Handle should not be called for a record
+// at that level.
+func (h *Handler) Enabled(ctx context.Context, lvl slog.Level) bool {
+	if h.discard {
+		return false
+	}
+
+	lvl = h.offsetLevel(lvl)
+	if h.lvlFunc != nil {
+		return h.lvlFunc(ctx, lvl)
+	}
+	return h.lvl.Level() <= lvl
+}
+
+const (
+	timeDelim    = " "  // separator between time and level
 	lvlDelim     = " "  // separator between level and message
 	groupDelim   = "."  // separator between group names
 	msgAttrDelim = "  " // separator between message and attributes
@@ -123,6 +1334,16 @@ const (
 	indent       = "  " // indentation for multi-line attributes
 )
 
+// newlineIndent is the start of a new indented line.
+// Attributes that follow it need no other delimiter.
+var newlineIndent = []byte("\n" + indent)
+
+// newlineEscaper escapes line breaks in single-line messages.
+var newlineEscaper = strings.NewReplacer(
+	"\n", `\n`,
+	"\r", `\r`,
+)
+
 // Handle writes the given log record to the output writer.
 //
 // The write is synchronized with a mutex,
@@ -130,72 +1351,396 @@ const (
 // (e.g. those made with WithAttrs, WithPrefix, etc.)
 // can be used concurrently without issues
 // as long as they all are built from the same base handler.
-func (h *Handler) Handle(_ context.Context, rec slog.Record) error {
-	bs := *takeBuf()
-	defer releaseBuf(&bs)
+func (h *Handler) Handle(ctx context.Context, rec slog.Record) (err error) {
+	if h.discard {
+		return nil
+	}
 
 	// Level
-	lvl := rec.Level + slog.Level(h.lvlOffset)
-	var lvlString string
-	if h.replaceAttr == nil {
-		lvlString = h.style.LevelLabels[lvl].String()
-	} else {
-		attr := h.replaceAttr(nil, slog.Any(slog.LevelKey, lvl))
-		if !attr.Equal(slog.Attr{}) {
-			if lvl, ok := attr.Value.Any().(slog.Level); ok {
-				// If the value is a known slog.Level,
-				// we can use the level label from the style.
-				lvlString = h.style.LevelLabels[lvl].String()
-			} else {
-				// Otherwise, just use the string representation.
-				lvlString = attr.Value.String()
-				// TODO: silog.Styled(lipgloss.Style, slog.Attr)
+	lvl := h.offsetLevel(rec.Level)
+	if h.lvlFunc != nil && !h.lvlFunc(ctx, lvl) {
+		// slog.Logger always checks Enabled before Handle,
+		// but other callers of Handle may not.
+		return nil
+	}
+
+	if h.respectCtx && ctx != nil {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+
+	if fallback := h.fallback; fallback != nil {
+		// Like the hook, this runs after locks are released.
+		msg := rec.Message
+		defer func() {
+			if err != nil {
+				fallback.write(lvl, msg, err)
 			}
+		}()
+	}
+
+	if hook := h.hook; hook != nil {
+		// Deferred before any locks are taken
+		// so that it runs after they're released.
+		orig := rec
+		defer func() {
+			if err == nil {
+				hook.run(lvl, orig)
+			}
+		}()
+	}
+
+	if counts := h.counts; counts != nil {
+		// Only records that were written are counted.
+		defer func() {
+			if err == nil {
+				counts.add(lvl)
+			}
+		}()
+	}
+
+	if h.now != nil && (h.forceClock || rec.Time.IsZero()) {
+		rec.Time = h.now()
+	}
+
+	prefix := h.prefix
+	if h.prefixFromCtx != nil && ctx != nil {
+		if p := h.prefixFromCtx(ctx); p != "" {
+			prefix = p
+		}
+	}
+	if h.ctxPrefix != ContextPrefixIgnore && ctx != nil {
+		if p := contextPrefix(ctx, h.prefixSep); p != "" {
+			if h.ctxPrefix == ContextPrefixAppend && prefix != "" {
+				p = prefix + h.prefixSep + p
+			}
+			prefix = p
 		}
 	}
 
-	// Time
-	var timeString string
-	if !rec.Time.IsZero() {
-		if h.replaceAttr == nil {
-			timeString = rec.Time.Format(h.timeFormat)
-		} else {
-			timeAttr := h.replaceAttr(nil, slog.Time(slog.TimeKey, rec.Time))
-			switch {
-			case timeAttr.Equal(slog.Attr{}):
-				// Skip the time.
-
-			case timeAttr.Value.Kind() == slog.KindTime:
-				// If the value is a time, format it with TimeFormat.
-				timeString = timeAttr.Value.Time().Format(h.timeFormat)
-
-			default:
-				// Otherwise, just use the string representation of the value.
-				timeString = timeAttr.Value.String()
+	if h.repeats != nil {
+		// Records must be rendered and written one at a time
+		// for "previous record" to be meaningful.
+		h.repeats.mu.Lock()
+		defer h.repeats.mu.Unlock()
+		defer h.repeats.commit()
+	}
+	if h.lastTime != nil {
+		h.lastTime.mu.Lock()
+		defer h.lastTime.mu.Unlock()
+	}
+	var delta string
+	if h.deltas != nil {
+		h.deltas.mu.Lock()
+		defer h.deltas.mu.Unlock()
+		delta = h.deltas.delta(rec.Time)
+	}
+
+	bs := *takeBuf(h.bufPool)
+	defer releaseBuf(h.bufPool, &bs)
+
+	var goroutine uint64
+	if h.showGoroutine {
+		goroutine = goroutineID()
+	}
+
+	out, outMu := h.output(lvl)
+	elide := h.repeats != nil || h.lastTime != nil
+	if h.detailOut == nil {
+		bs = h.appendRecord(bs, lvl, rec, recordView{prefix: prefix, elide: elide, goroutine: goroutine, delta: delta})
+		if h.stats != nil {
+			h.stats.add(bs)
+		}
+
+		return h.writeRecords(lvl, []*sync.Mutex{outMu}, pendingWrite{out, bs})
+	}
+
+	// With a detail writer, the primary writer gets a summary,
+	// and the detail writer gets the full record.
+	// Both are tagged with a reference to tie them together.
+	ref := slog.Uint64(detailRefKey, h.detailSeq.Add(1))
+	bs = h.appendRecord(bs, lvl, rec, recordView{summary: true, ref: ref, prefix: prefix, elide: elide, goroutine: goroutine, delta: delta})
+
+	detail := *takeBuf(h.bufPool)
+	defer releaseBuf(h.bufPool, &detail)
+	detail = h.appendRecord(detail, lvl, rec, recordView{ref: ref, prefix: prefix, goroutine: goroutine, delta: delta})
+	if h.stats != nil {
+		h.stats.add(bs, detail)
+	}
+
+	// The detail writer is synchronized with the main writer.
+	// If the summary goes to a different writer, lock that too.
+	mus := []*sync.Mutex{h.outMu}
+	if outMu != h.outMu {
+		mus = append(mus, outMu)
+	}
+	return h.writeRecords(lvl, mus,
+		pendingWrite{out, bs},
+		pendingWrite{h.detailOut, detail},
+	)
+}
+
+// Banner writes the given lines to the handler's output
+// with Style.Banner, without a time, level, or prefix.
+// Use it to write a header before any logs,
+// e.g. with the version of a program:
+//
+//	handler.Banner("myapp v1.2.3", "Started at "+time.Now().Format(time.RFC3339))
+//
+// Lines may contain newlines.
+// All lines are written with a single Write call
+// synchronized with records logged by the handler,
+// regardless of the handler's level.
+func (h *Handler) Banner(lines ...string) error {
+	if h.discard || len(lines) == 0 {
+		return nil
+	}
+
+	bs := *takeBuf(h.bufPool)
+	defer releaseBuf(h.bufPool, &bs)
+
+	for _, line := range lines {
+		line = strings.TrimSuffix(line, "\n")
+		if h.stripANSI {
+			line = ansi.Strip(line)
+		}
+		bs = appendStyledLines(bs, []byte(line+"\n"), h.style.Banner)
+	}
+
+	h.outMu.Lock()
+	defer h.outMu.Unlock()
+	_, err := h.out.Write(bs)
+	return err
+}
+
+// levelOutput is a writer for records at or above a level.
+type levelOutput struct {
+	lvl slog.Level
+	w   io.Writer
+	mu  *sync.Mutex // shared by all levelOutputs with the same writer
+}
+
+// newLevelOutputs builds levelOutputs from HandlerOptions.LevelWriters.
+// out and outMu are the handler's main writer and its mutex.
+func newLevelOutputs(out io.Writer, outMu *sync.Mutex, writers map[slog.Level]io.Writer) []levelOutput {
+	if len(writers) == 0 {
+		return nil
+	}
+
+	type writerMutex struct {
+		w  io.Writer
+		mu *sync.Mutex
+	}
+	mutexes := []writerMutex{{w: out, mu: outMu}}
+	mutexFor := func(w io.Writer) *sync.Mutex {
+		for _, m := range mutexes {
+			if sameWriter(m.w, w) {
+				return m.mu
 			}
 		}
+		mu := new(sync.Mutex)
+		mutexes = append(mutexes, writerMutex{w: w, mu: mu})
+		return mu
+	}
+
+	outs := make([]levelOutput, 0, len(writers))
+	for _, lvl := range slices.Sorted(maps.Keys(writers)) {
+		w := writers[lvl]
+		if w == nil {
+			continue
+		}
+		outs = append(outs, levelOutput{lvl: lvl, w: w, mu: mutexFor(w)})
+	}
+	slices.Reverse(outs)
+	return outs
+}
+
+// sameWriter reports whether a and b are the same writer.
+// Writers with types that can't be compared (e.g. slices)
+// are never the same.
+func sameWriter(a, b io.Writer) bool {
+	ta, tb := reflect.TypeOf(a), reflect.TypeOf(b)
+	return ta != nil && ta == tb && ta.Comparable() && a == b
+}
+
+// output returns the writer for records at the given level
+// and the mutex that synchronizes writes to it.
+func (h *Handler) output(lvl slog.Level) (io.Writer, *sync.Mutex) {
+	for _, o := range h.levelOuts {
+		if lvl >= o.lvl {
+			return o.w, o.mu
+		}
 	}
-	if timeString != "" {
+	return h.out, h.outMu
+}
+
+// detailRefKey is the key of the attribute that ties together
+// records written to the primary and detail writers.
+const detailRefKey = "ref"
+
+// recordView specifies which attributes of a record to render.
+type recordView struct {
+	// summary renders only the attributes listed in summaryKeys.
+	summary bool
+
+	// ref, if non-empty, is added to the end of the attributes.
+	ref slog.Attr
+
+	// prefix is the prefix for the record.
+	prefix string
+
+	// elide replaces times and attribute values
+	// repeated from the previous record.
+	elide bool
+
+	// goroutine is the ID of the goroutine that logged the record,
+	// or 0 if it should not be shown.
+	goroutine uint64
+
+	// delta is the time since the previous record, e.g. "+12ms",
+	// or empty if it should not be shown.
+	delta string
+}
+
+// appendRecord renders a log record to dst.
+//
+// lvl is the level of the record after the level offset.
+func (h *Handler) appendRecord(dst []byte, lvl slog.Level, rec slog.Record, view recordView) []byte {
+	if h.sanitizeUTF8 {
+		rec.Message = toValidUTF8(rec.Message)
+	}
+	rec.Message = h.msgCase.apply(rec.Message)
+	if h.format == FormatTSV {
+		return h.appendTSVRecord(dst, lvl, rec, view)
+	}
+	if h.singleLineMsg {
+		rec.Message = newlineEscaper.Replace(rec.Message)
+	}
+
+	lineStyle, hasLineStyle := h.style.Lines[lvl]
+	if !hasLineStyle && h.baseIndent == "" {
+		return h.appendRawRecord(dst, lvl, rec, view)
+	}
+
+	bs := *takeBuf(h.bufPool)
+	defer releaseBuf(h.bufPool, &bs)
+
+	bs = h.appendRawRecord(bs, lvl, rec, view)
+	if h.baseIndent == "" {
+		return appendStyledLines(dst, bs, lineStyle)
+	}
+
+	// The base indent is not part of the line style.
+	for line := range bytes.Lines(bs) {
+		dst = append(dst, h.baseIndent...)
+		if hasLineStyle {
+			dst = appendStyledLines(dst, line, lineStyle)
+		} else {
+			dst = append(dst, line...)
+		}
+	}
+	return dst
+}
+
+// appendRawRecord renders a log record to bs
+// without applying line styles.
+func (h *Handler) appendRawRecord(bs []byte, lvl slog.Level, rec slog.Record, view recordView) []byte {
+	lvlString := h.levelString(lvl)
+	timeString := h.timeString(lvl, rec.Time)
+	if view.elide && h.lastTime != nil {
+		timeString = h.lastTime.elide(timeString)
+	}
+	if strings.TrimSpace(timeString) != "" {
 		timeString = h.style.Time.Render(timeString)
 	}
+	if view.delta != "" && timeString != "" {
+		timeString += " " + h.style.TimeDelta.Render("("+view.delta+")")
+	}
+
+	prefix := h.prefixString(view.prefix)
+
+	var goroutine string
+	if view.goroutine != 0 {
+		goroutine = h.style.GoroutineID.Render("go" + strconv.FormatUint(view.goroutine, 10))
+	}
+
+	// If attributes are deferred,
+	// arrange them now, as some may precede the message.
+	var (
+		attrs   []groupedAttr
+		leading []byte
+		omitted int // number of attributes omitted for maxAttrs
+	)
+	if h.deferAttrs {
+		attrs = h.arrangeAttrs(rec, view)
+		numAttrs := len(attrs)
+		if len(h.leadingAttrs) > 0 {
+			attrs, leading = h.takeLeadingAttrs(lvl, attrs)
+			defer releaseBuf(h.bufPool, &leading)
+		}
+		if h.maxAttrs > 0 {
+			// Leading attributes count first.
+			shown := max(h.maxAttrs-(numAttrs-len(attrs)), 0)
+			if len(attrs) > shown {
+				omitted = len(attrs) - shown
+				attrs = attrs[:shown]
+			}
+		}
+	}
 
 	// If the message is multi-line,
-	// we'll need to prepend the level and time to each line.
+	// we'll need to prepend the level and time to each line,
+	// or with indentCont, indent lines after the first to align with it.
+	var (
+		msgWidth   int    // width of the last line of the message
+		contIndent string // indentation of continuation lines
+	)
 	for line := range strings.Lines(rec.Message) {
-		if timeString != "" {
-			bs = append(bs, timeString...)
-			bs = append(bs, timeDelim...)
-		}
-		if lvlString != "" {
-			bs = append(bs, lvlString...)
-			bs = append(bs, lvlDelim...)
+		linePrefix := prefix
+		if contIndent != "" {
+			bs = append(bs, contIndent...)
+			linePrefix = ""
+		} else {
+			lineStart := len(bs)
+			if timeString != "" {
+				bs = append(bs, timeString...)
+				bs = append(bs, timeDelim...)
+			}
+			if lvlString != "" {
+				bs = append(bs, lvlString...)
+				bs = append(bs, lvlDelim...)
+			}
+			if goroutine != "" {
+				bs = append(bs, goroutine...)
+				bs = append(bs, lvlDelim...)
+			}
+			if len(leading) > 0 {
+				bs = append(bs, leading...)
+				bs = append(bs, lvlDelim...)
+			}
+			if h.metaWidth > 0 {
+				// The prefix goes in the metadata column.
+				bs = append(bs, prefix...)
+				linePrefix = ""
+				bs = h.appendColumnRule(bs, lineStart)
+			}
+			if h.indentCont {
+				width := ansi.StringWidth(string(bs[lineStart:]))
+				if linePrefix != "" {
+					width += lipgloss.Width(prefix)
+				}
+				contIndent = strings.Repeat(" ", width)
+				if h.metaWidth > 0 {
+					// Keep the rule on continuation lines.
+					width -= ansi.StringWidth(h.columnRule) + 1
+					contIndent = strings.Repeat(" ", width) + h.columnRule + " "
+				}
+			}
 		}
 
 		var msg bytes.Buffer
-		if h.prefix != "" {
-			msg.WriteString(h.prefix)
-			msg.WriteString(h.style.PrefixDelimiter.Render())
-		}
+		msg.WriteString(linePrefix)
 
 		// line may end with \n.
 		// That should not be included in the rendering logic.
@@ -204,71 +1749,471 @@ func (h *Handler) Handle(_ context.Context, rec slog.Record) error {
 			trailingNewline = true
 			line = line[:len(line)-1]
 		}
-		msg.WriteString(line)
-
-		line = h.style.Messages[lvl].Render(msg.String())
-		bs = append(bs, line...)
+		if h.stripANSI {
+			line = ansi.Strip(line)
+		}
+		if h.highlightPairs {
+			msg.WriteString(highlightPairs(line, h.style.Key))
+			// Render would clear the message style
+			// after each highlighted key.
+			bs = appendStyledLines(bs, msg.Bytes(), h.style.Messages[lvl])
+		} else {
+			msg.WriteString(line)
+			bs = append(bs, h.style.Messages[lvl].Render(msg.String())...)
+		}
 		if trailingNewline {
 			bs = append(bs, '\n')
 		}
+		if h.msgWidth > 0 {
+			msgWidth = lipgloss.Width(msg.String())
+			if linePrefix == "" && h.metaWidth == 0 {
+				// Continuation lines are indented past the prefix.
+				msgWidth += lipgloss.Width(prefix)
+			}
+		}
 	}
 
-	// First attribute after the message is separated by two spaces.
-	bs = append(bs, msgAttrDelim...)
+	msgEnd := len(bs)
+	if h.attrsOnNewLine || h.verboseAttrs || bytes.HasSuffix(bs, []byte{'\n'}) {
+		// Attributes start on their own indented line.
+		// This is also the case if the message ends with a newline,
+		// in which case there's no separator after the message.
+		if len(bs) > 0 && bs[len(bs)-1] != '\n' {
+			bs = append(bs, '\n')
+		}
+		bs = append(bs, indent...)
+	} else {
+		// Pad the message so attributes line up.
+		if pad := h.msgWidth - msgWidth; pad > 0 && len(bs) > 0 && bs[len(bs)-1] != '\n' {
+			bs = append(bs, strings.Repeat(" ", pad)...)
+		}
 
-	// withAttrs attributes are serialized into the buffer
-	if len(h.attrs) > 0 {
-		bs = append(bs, h.attrs...)
+		// First attribute after the message is separated by two spaces.
+		bs = append(bs, msgAttrDelim...)
 	}
+	attrsStart := len(bs)
 
-	// Write the attributes.
-	formatter := h.attrFormatter(bs)
-	rec.Attrs(func(attr slog.Attr) bool {
-		formatter.FormatAttr(attr)
-		return true
-	})
-	bs = formatter.buf
+	var multiline bool // whether any attributes are multi-line
+	if h.deferAttrs {
+		f := h.attrFormatter(bs)
+		f.lvl = lvl
+		if view.elide {
+			f.repeats = h.repeats
+		}
+		if h.verboseAttrs {
+			for _, a := range attrs {
+				f.keyWidth = max(f.keyWidth, f.keyTextWidth(a.groups, a.attr.Key))
+			}
+		}
+		for _, a := range attrs {
+			f.writeAttr(a.groups, a.attr)
+		}
+		f.closeBraces(0)
+		if omitted > 0 {
+			f.writeMoreAttrs(omitted)
+		}
+		bs = f.buf
+		multiline = f.multiline
+	} else {
+		// withAttrs attributes are serialized into the buffer
+		if len(h.attrs) > 0 {
+			attrs := h.attrs
+			if bytes.HasSuffix(bs, newlineIndent) {
+				// Already on a new line.
+				// Multi-line attributes don't need another.
+				attrs = bytes.TrimPrefix(attrs, newlineIndent)
+			}
+			bs = append(bs, attrs...)
+		}
+
+		// Write the attributes.
+		formatter := h.attrFormatter(bs)
+		formatter.lvl = lvl
+		rec.Attrs(func(attr slog.Attr) bool {
+			formatter.FormatAttr(attr)
+			return true
+		})
+		bs = formatter.buf
+		multiline = h.attrsMultiline || formatter.multiline
+	}
+
+	if multiline && h.multilineMarker != "" {
+		// The marker goes after the message,
+		// before the newline that ends it, if any.
+		at := msgEnd
+		if at > 0 && bs[at-1] == '\n' {
+			at--
+		}
+		marker := " " + h.multilineMarker
+		bs = slices.Insert(bs, at, []byte(marker)...)
+		attrsStart += len(marker)
+	}
 
 	// Always a single trailing newline.
-	bs = append(bytes.TrimRight(bs, " \n"), '\n')
+	if h.preserveSpace {
+		// Remove only the delimiter we added
+		// if there were no attributes.
+		if len(bs) == attrsStart {
+			bs = bs[:msgEnd]
+		}
+		bs = bytes.TrimRight(bs, "\n")
+	} else {
+		bs = bytes.TrimRight(bs, " \n")
+	}
+	if len(bs) > attrsStart && styleStart(h.style.AttrsContainer) != "" {
+		attrs := *takeBuf(h.bufPool)
+		defer releaseBuf(h.bufPool, &attrs)
 
-	h.outMu.Lock()
-	defer h.outMu.Unlock()
-	_, err := h.out.Write(bs)
+		attrs = append(attrs[:0], bs[attrsStart:]...)
+		bs = appendStyledLines(bs[:attrsStart], attrs, h.style.AttrsContainer)
+	}
+	return append(bs, '\n')
+}
+
+// levelString returns the rendered level label for a record,
+// after applying ReplaceAttr.
+// It returns an empty string if the level should be omitted.
+func (h *Handler) levelString(lvl slog.Level) string {
+	return h.replaceLevel(lvl, h.levelLabel)
+}
+
+// levelNameString is like levelString,
+// but returns the canonical name of the level instead of its label.
+func (h *Handler) levelNameString(lvl slog.Level) string {
+	return h.replaceLevel(lvl, h.levelName)
+}
+
+// replaceLevel applies ReplaceAttr to the level
+// and renders the result with render if it's still a level.
+func (h *Handler) replaceLevel(lvl slog.Level, render func(slog.Level) string) string {
+	if h.replaceAttr == nil {
+		return render(lvl)
+	}
+
+	attr := h.replaceAttr(nil, slog.Any(slog.LevelKey, lvl))
+	if attr.Equal(slog.Attr{}) {
+		return ""
+	}
+	if lvl, ok := attr.Value.Any().(slog.Level); ok {
+		// If the value is a known slog.Level,
+		// we can render it with the style.
+		return render(lvl)
+	}
+
+	// Otherwise, just use the string representation.
+	// TODO: silog.Styled(lipgloss.Style, slog.Attr)
+	return attr.Value.String()
+}
+
+// timeString returns the unstyled timestamp for a record at lvl,
+// after applying ReplaceAttr.
+// It returns an empty string if the time should be omitted.
+func (h *Handler) timeString(lvl slog.Level, t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	layout := h.timeFormat
+	if f, ok := h.levelTimeFormats[lvl]; ok {
+		layout = f
+	}
+	if h.replaceAttr == nil {
+		return t.Format(layout)
+	}
+
+	timeAttr := h.replaceAttr(nil, slog.Time(slog.TimeKey, t))
+	switch {
+	case timeAttr.Equal(slog.Attr{}):
+		// Skip the time.
+		return ""
+
+	case timeAttr.Value.Kind() == slog.KindTime:
+		// If the value is a time, format it with TimeFormat.
+		return timeAttr.Value.Time().Format(layout)
+
+	default:
+		// Otherwise, just use the string representation of the value.
+		return timeAttr.Value.String()
+	}
+}
+
+// levelLabel returns the rendered label for the given level.
+func (h *Handler) levelLabel(lvl slog.Level) string {
+	if h.renderLevel != nil {
+		return h.renderLevel(lvl, h.style)
+	}
+	return h.style.LevelLabels[lvl].String()
+}
+
+// levelName returns the canonical name of the given level.
+func (h *Handler) levelName(lvl slog.Level) string {
+	if h.style.LevelName != nil {
+		return h.style.LevelName(lvl)
+	}
+	if name, ok := h.style.LevelNames[lvl]; ok {
+		return name
+	}
+	if label := h.levelLabel(lvl); label != "" {
+		return label
+	}
+	return lvl.String()
+}
+
+// pendingWrite is a rendered log record and the writer it goes to.
+type pendingWrite struct {
+	w  io.Writer
+	bs []byte
+}
+
+// writeRecords writes rendered log records to their writers
+// while holding the given output locks,
+// giving up if that takes longer than the write timeout.
+//
+// A write that times out continues in the background,
+// and keeps holding the output locks until it finishes.
+func (h *Handler) writeRecords(lvl slog.Level, mus []*sync.Mutex, writes ...pendingWrite) error {
+	if h.writeTimeout <= 0 {
+		lockAll(mus)
+		defer unlockAll(mus)
+		return writeAll(lvl, writes)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), h.writeTimeout)
+	defer cancel()
+
+	// Wait for writes that timed out earlier to finish.
+	select {
+	case h.writeSem <- struct{}{}:
+	case <-ctx.Done():
+		return fmt.Errorf("write timed out after %v: %w", h.writeTimeout, ctx.Err())
+	}
+
+	// The write may outlive this call,
+	// so it cannot use buffers that will go back to the pool.
+	owned := make([]pendingWrite, len(writes))
+	for i, pw := range writes {
+		owned[i] = pendingWrite{pw.w, slices.Clone(pw.bs)}
+	}
+
+	// The locks are released by the write, not by this call,
+	// so that Banner, FileHandler.Rotate, and FileHandler.Close
+	// wait for a write that timed out.
+	lockAll(mus)
+	done := make(chan error, 1)
+	go func() {
+		defer func() { <-h.writeSem }()
+		defer unlockAll(mus)
+		done <- writeAll(lvl, owned)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("write timed out after %v: %w", h.writeTimeout, ctx.Err())
+	}
+}
+
+// lockAll locks the given mutexes in order.
+func lockAll(mus []*sync.Mutex) {
+	for _, mu := range mus {
+		mu.Lock()
+	}
+}
+
+// unlockAll unlocks the given mutexes in reverse order.
+func unlockAll(mus []*sync.Mutex) {
+	for _, mu := range slices.Backward(mus) {
+		mu.Unlock()
+	}
+}
+
+// writeAll writes rendered log records to their writers.
+func writeAll(lvl slog.Level, writes []pendingWrite) error {
+	var errs []error
+	for _, pw := range writes {
+		errs = append(errs, writeRecord(pw.w, lvl, pw.bs))
+	}
+	return errors.Join(errs...)
+}
+
+// writeRecord writes a rendered log record to w.
+// The caller must hold the output lock.
+func writeRecord(w io.Writer, lvl slog.Level, bs []byte) error {
+	var err error
+	if lw, ok := w.(LevelWriter); ok {
+		_, err = lw.WriteLevel(lvl, bs)
+	} else {
+		_, err = w.Write(bs)
+	}
 	return err
 }
 
+// prefixString returns the given prefix and its delimiter
+// to write before each line of the message,
+// padded to the configured prefix width.
+func (h *Handler) prefixString(prefix string) string {
+	before, after := h.prefixAffixes()
+	if prefix != "" {
+		prefix = before + elideMiddle(prefix, h.maxPrefixLen) + after
+	}
+
+	if h.prefixWidth > 0 {
+		width := h.prefixWidth + lipgloss.Width(before) + lipgloss.Width(after)
+		if pad := width - lipgloss.Width(prefix); pad > 0 {
+			prefix += strings.Repeat(" ", pad)
+		}
+	}
+
+	return prefix
+}
+
+// appendColumnRule pads the metadata column that starts at bs[start:]
+// to the column width, and appends the column rule.
+func (h *Handler) appendColumnRule(bs []byte, start int) []byte {
+	if pad := h.metaWidth - ansi.StringWidth(string(bs[start:])); pad > 0 {
+		bs = append(bs, strings.Repeat(" ", pad)...)
+	}
+	bs = append(bs, h.columnRule...)
+	return append(bs, ' ')
+}
+
+// prefixAffixes returns the text that goes before and after a prefix.
+func (h *Handler) prefixAffixes() (before, after string) {
+	if h.style.PrefixFormat == PrefixFormatBracketed {
+		before = cmp.Or(h.style.PrefixOpen.Render(), "[")
+		after = cmp.Or(h.style.PrefixClose.Render(), "]") + " "
+		return before, after
+	}
+	return "", h.style.PrefixDelimiter.Render()
+}
+
+// elideMiddle shortens s to at most n runes
+// by replacing runes in its middle with "…".
+// s is returned unchanged if n is zero or s is short enough.
+func elideMiddle(s string, n int) string {
+	if n <= 0 || utf8.RuneCountInString(s) <= n {
+		return s
+	}
+
+	runes := []rune(s)
+	keep := n - 1 // room for the ellipsis
+	head := keep - keep/2
+	tail := keep / 2
+	return string(runes[:head]) + "…" + string(runes[len(runes)-tail:])
+}
+
 // WithAttrs returns a copy of this handler
 // that will always include the given slog attributes
 // in its output.
 func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
-	f := h.attrFormatter(slices.Clone(h.attrs))
-	for _, attr := range attrs {
-		f.FormatAttr(attr)
+	if h.discard {
+		return h
 	}
-	bs := f.buf
 
 	newH := *h
-	newH.attrs = bs
+	newH.addAttrs(attrs)
 	return &newH
 }
 
+// addAttrs adds the given attributes to the handler's own attributes.
+// It must only be called on a newly created handler or copy.
+func (h *Handler) addAttrs(attrs []slog.Attr) {
+	if h.deferAttrs {
+		f := h.attrFormatter(nil)
+		h.groupedAttrs = f.collectAttrs(slices.Clip(h.groupedAttrs), attrs)
+		return
+	}
+
+	f := h.attrFormatter(slices.Clone(h.attrs))
+	for _, attr := range attrs {
+		f.FormatAttr(attr)
+	}
+	h.attrs = f.buf
+	h.attrsMultiline = h.attrsMultiline || f.multiline
+}
+
 // WithGroup returns a copy of this handler
 // that will always group the attributes that follow
 // under the given group name.
+//
+// Groups nest: WithGroup("a").WithGroup("a") produces keys like "a.a.k".
+// This is usually a mistake, e.g. when helper functions
+// each add the same group.
+// Set HandlerOptions.DedupGroups to ignore such repeated groups.
 func (h *Handler) WithGroup(name string) slog.Handler {
+	if h.dedupGroups && len(h.groups) > 0 && h.groups[len(h.groups)-1] == name {
+		return h
+	}
+
 	newH := *h
 	newH.groups = append(slices.Clone(h.groups), name)
 	return &newH
 }
 
+// WithGroupLevel returns a new handler that groups attributes
+// under the given group name like [Handler.WithGroup],
+// and has the given leveler like [Handler.WithLevel].
+// Use it to set levels for parts of a program
+// as the loggers for them are built:
+//
+//	dbLogger := slog.New(handler.WithGroupLevel("db", slog.LevelWarn))
+//
+// The level applies to records logged through the returned handler
+// and handlers derived from it,
+// so the most deeply nested WithGroupLevel wins.
+// It replaces any level function set with [Handler.WithLevelFunc],
+// which would otherwise take precedence over the level.
+// It does not apply to attributes grouped with slog.Group,
+// or to groups opened on other handlers.
+func (h *Handler) WithGroupLevel(name string, lvl slog.Leveler) *Handler {
+	return h.WithGroup(name).(*Handler).WithLevel(lvl).WithLevelFunc(nil)
+}
+
 // WithLevel returns a new handler with the given leveler,
 // retaining all other attributes and groups.
 //
 // It will write to the same output writer as this handler.
+//
+// The leveler is consulted on every call to Enabled, not cached.
+// If it's dynamic (e.g. a *slog.LevelVar),
+// changes to it take effect immediately for the returned handler
+// and all handlers derived from it.
+// Level offsets (see [Handler.WithLevelOffset]) are applied
+// to the level of each record, not to the leveler,
+// so they continue to apply as the leveler changes.
 func (h *Handler) WithLevel(lvl slog.Leveler) *Handler {
 	newH := *h
-	newH.lvl = lvl
+	newH.lvl = lvl
+	return &newH
+}
+
+// Level returns the minimum log level of this handler.
+//
+// If the handler was configured with a dynamic slog.Leveler
+// (e.g. *slog.LevelVar), this reports its current level.
+// It does not account for the level offset (see [Handler.LevelOffset])
+// or a level function set with [Handler.WithLevelFunc].
+func (h *Handler) Level() slog.Level {
+	return h.lvl.Level()
+}
+
+// WithLevelFunc returns a copy of this handler
+// that uses the given function to decide whether a record is logged.
+//
+// The function is called with the context of the log call
+// and the level of the record after the level offset
+// (see [Handler.WithLevelOffset]) has been applied.
+// It overrides the static level check:
+// records are logged if and only if the function returns true,
+// regardless of the configured level.
+// The level offset continues to apply to the rendered level.
+//
+// Use this for sampling, or verbosity that depends on the context
+// (e.g. per-tenant or per-trace debug logging).
+// Pass nil to go back to the static level check.
+func (h *Handler) WithLevelFunc(fn func(ctx context.Context, lvl slog.Level) bool) *Handler {
+	newH := *h
+	newH.lvlFunc = fn
 	return &newH
 }
 
@@ -312,30 +2257,207 @@ func (h *Handler) WithLevelOffset(n int) *Handler {
 	return &newH
 }
 
+// offsetLevel applies the level offset to lvl.
+func (h *Handler) offsetLevel(lvl slog.Level) slog.Level {
+	newLvl := lvl + slog.Level(h.lvlOffset)
+	if !h.clampOffset {
+		return newLvl
+	}
+
+	// Don't move past the ends of the range,
+	// or further out if already outside it.
+	switch {
+	case h.lvlOffset < 0:
+		return max(newLvl, min(lvl, h.lvlMin))
+	case h.lvlOffset > 0:
+		return min(newLvl, max(lvl, h.lvlMax))
+	default:
+		return newLvl
+	}
+}
+
 // LevelOffset returns the current level offset for this handler, if any.
 func (h *Handler) LevelOffset() int {
 	return h.lvlOffset
 }
 
+// TimeFormat returns the layout used to render timestamps.
+func (h *Handler) TimeFormat() string {
+	return h.timeFormat
+}
+
+// ColorProfile returns the color profile of the output
+// as specified with HandlerOptions.ColorProfile.
+//
+// Use it to make color-dependent decisions consistently with the handler,
+// e.g. whether to pre-render attribute values with colors.
+func (h *Handler) ColorProfile() colorprofile.Profile {
+	return h.profile
+}
+
+// Style returns a copy of the style used by this handler.
+//
+// The returned style is a snapshot:
+// changes made to it do not affect the handler.
+func (h *Handler) Style() *Style {
+	return h.style.clone()
+}
+
+// maxGroupDepth is the maximum number of nested group attributes
+// that will be rendered for a single attribute.
+//
+// slog.Value.Resolve limits the number of LogValue calls for one value,
+// but a LogValuer may return a group containing itself,
+// which would otherwise recurse forever.
+const maxGroupDepth = 100
+
 type attrFormatter struct {
 	buf    []byte
 	style  *Style
 	groups []string
 
+	// depth is the number of group attributes
+	// currently being rendered.
+	depth int
+
 	replaceAttr func([]string, slog.Attr) slog.Attr
+	bufPool     BufferPool
+
+	// colorDelimiter renders the key-value delimiter
+	// with the foreground color of styled values.
+	colorDelimiter bool
+
+	// neutralPrefix renders the multi-line value prefix
+	// without the foreground color of styled values.
+	neutralPrefix bool
+
+	// colorKey renders keys
+	// with the foreground color of styled values.
+	colorKey bool
+
+	// anyFormat is the format for composite values.
+	anyFormat AnyFormat
+
+	// sliceJoin joins the elements of scalar slices if non-empty.
+	sliceJoin string
+
+	// verbose formats fmt.Formatter values with %+v.
+	verbose bool
+
+	// promoteErrs extracts fields from error messages.
+	promoteErrs bool
+
+	// durationFormat is the format for time.Duration values.
+	durationFormat DurationFormat
+
+	// inlineMaxLen is the maximum width of inline values.
+	inlineMaxLen int
+
+	// stripANSI removes escape sequences from values.
+	stripANSI bool
+
+	// sanitizeUTF8 replaces invalid UTF-8 in values.
+	sanitizeUTF8 bool
+
+	// boolGlyphs renders booleans with Style.BoolGlyphs.
+	boolGlyphs bool
+
+	// asciiBars draws Style.ValueBars with ASCII characters.
+	asciiBars bool
+
+	// flattenKeys omits group names from keys.
+	flattenKeys bool
+
+	// digitSep separates groups of digits in numbers if non-empty.
+	digitSep string
+
+	// repeats, if set, is used to elide values
+	// repeated from the previous record.
+	repeats *repeatCache
+
+	// groupBraces encloses members of groups in braces.
+	groupBraces bool
+
+	// groupDepth is the maximum number of groups
+	// rendered in dotted keys, or zero for no limit.
+	groupDepth int
+
+	// braced is the group path of the open braces,
+	// and braces is the number of groups opened by each brace.
+	braced []string
+	braces []int
+
+	// keyWidth, if positive, writes each attribute on its own line
+	// with keys right-aligned to this width.
+	keyWidth int
+
+	// treePath holds the branches of the last attribute
+	// rendered as a tree because it exceeded groupDepth,
+	// or nil if the last attribute was not rendered as a tree.
+	treePath []string
+
+	// multiline is set once a multi-line attribute is written.
+	multiline bool
+
+	// lvl is the level of the record being formatted.
+	// It's unset when formatting attributes outside a record
+	// (e.g. in WithAttrs).
+	lvl slog.Level
 }
 
 func (h *Handler) attrFormatter(buf []byte) *attrFormatter {
+	replaceAttr := h.replaceAttr
+	if replaceAttr != nil && len(h.replaceGroups) > 0 {
+		outer := h.replaceGroups
+		replaceAttr = func(groups []string, attr slog.Attr) slog.Attr {
+			return h.replaceAttr(append(slices.Clip(outer), groups...), attr)
+		}
+	}
+
 	return &attrFormatter{
 		buf:         buf,
+		bufPool:     h.bufPool,
 		style:       h.style,
 		groups:      slices.Clone(h.groups),
-		replaceAttr: h.replaceAttr,
+		replaceAttr: replaceAttr,
+
+		colorDelimiter: h.colorDelimiter,
+		neutralPrefix:  h.neutralPrefix,
+		colorKey:       h.colorKey,
+		anyFormat:      h.anyFormat,
+		verbose:        h.verbose,
+		sliceJoin:      h.sliceJoin,
+		promoteErrs:    h.promoteErrs,
+		durationFormat: h.durationFormat,
+		inlineMaxLen:   h.inlineMaxLen,
+		stripANSI:      h.stripANSI,
+		sanitizeUTF8:   h.sanitizeUTF8,
+		boolGlyphs:     h.boolGlyphs,
+		asciiBars:      !hasColor(h.profile),
+		flattenKeys:    h.flattenKeys,
+		digitSep:       h.digitSep,
+		groupBraces:    h.groupBraces,
+		groupDepth:     h.groupDepth,
 	}
 }
 
+// FormatAttr writes the given attribute to the buffer,
+// expanding groups into their members.
 func (f *attrFormatter) FormatAttr(attr slog.Attr) {
+	f.walkAttr(attr, f.writeAttr)
+}
+
+// walkAttr resolves the given attribute, applies ReplaceAttr to it,
+// and calls fn with it and its group path,
+// expanding groups into their members.
+//
+// Empty attributes are skipped.
+func (f *attrFormatter) walkAttr(attr slog.Attr, fn func([]string, slog.Attr)) {
 	attr.Value = attr.Value.Resolve()
+	if v, ok := attrSliceGroup(attr.Value); ok {
+		// []slog.Attr values are treated like groups.
+		attr.Value = v
+	}
 	if f.replaceAttr != nil {
 		attr = f.replaceAttr(f.groups, attr)
 	}
@@ -344,58 +2466,91 @@ func (f *attrFormatter) FormatAttr(attr slog.Attr) {
 		return // skip empty attributes
 	}
 
-	value := attr.Value
-	if value.Kind() == slog.KindGroup {
+	if f.promoteErrs && attr.Value.Kind() == slog.KindAny && slices.Contains(f.style.ErrorKeys, attr.Key) {
+		if err, ok := attr.Value.Any().(error); ok {
+			if msg, fields, ok := splitErrorFields(err.Error()); ok {
+				fn(f.groups, slog.String(attr.Key, msg))
+				for _, field := range fields {
+					fn(f.groups, field)
+				}
+				return
+			}
+		}
+	}
+
+	if attr.Value.Kind() == slog.KindGroup && f.depth >= maxGroupDepth {
+		attr.Value = slog.AnyValue(fmt.Errorf("exceeded maximum group depth (%d)", maxGroupDepth))
+	}
+
+	if attr.Value.Kind() == slog.KindGroup {
 		// Groups just get splatted into their attributes
 		// prefixed with the group name.
-		f.groups = append(f.groups, attr.Key)
-		for _, a := range value.Group() {
-			f.FormatAttr(a)
+		//
+		// Per slog convention, groups with empty keys are inlined:
+		// their members don't get a prefix,
+		// and ReplaceAttr doesn't see them in the group path.
+		inline := attr.Key == ""
+		if !inline {
+			f.groups = append(f.groups, attr.Key)
+		}
+		f.depth++
+		for _, a := range attr.Value.Group() {
+			f.walkAttr(a, fn)
+		}
+		f.depth--
+		if !inline {
+			f.groups = f.groups[:len(f.groups)-1]
 		}
-		f.groups = f.groups[:len(f.groups)-1]
 		return
 	}
 
+	fn(f.groups, attr)
+}
+
+// writeAttr writes a single non-group attribute to the buffer
+// with the given group path.
+func (f *attrFormatter) writeAttr(groups []string, attr slog.Attr) {
+	value := attr.Value
+	var (
+		forceMultiline bool
+		reader         io.Reader // streamed into the buffer if set
+	)
+	if value.Kind() == slog.KindAny {
+		switch v := value.Any().(type) {
+		case blockValue:
+			forceMultiline = true
+			value = slog.AnyValue(v.v).Resolve()
+		case readerValue:
+			forceMultiline = true
+			reader = v.r
+		}
+	}
+
 	// We serialize the attribute into a byte slice,
 	// and then decide how it goes into the output.
 	// This is because we need to handle multi-line attributes
 	// and indent them.
-	valbs := *takeBuf()
-	defer releaseBuf(&valbs)
-
-	switch value.Kind() {
-	case slog.KindBool:
-		valbs = strconv.AppendBool(valbs, value.Bool())
-	case slog.KindDuration:
-		valbs = append(valbs, value.Duration().String()...)
-	case slog.KindFloat64:
-		valbs = strconv.AppendFloat(valbs, value.Float64(), 'g', -1, 64)
-	case slog.KindInt64:
-		valbs = strconv.AppendInt(valbs, value.Int64(), 10)
-	case slog.KindString:
-		valbs = append(valbs, value.String()...)
-	case slog.KindTime:
-		valbs = value.Time().AppendFormat(valbs, time.Kitchen)
-	case slog.KindUint64:
-		valbs = strconv.AppendUint(valbs, value.Uint64(), 10)
-	default:
-		// TODO: reflection to handle structs, maps, slices, etc.
-		valbs = append(valbs, value.String()...)
+	valbs := *takeBuf(f.bufPool)
+	defer releaseBuf(f.bufPool, &valbs)
+	if reader == nil {
+		valbs = f.appendValue(valbs, value)
 	}
 
-	// Add delimiter between attrs.
-	if len(f.buf) > 0 {
-		switch {
-		case f.buf[len(f.buf)-1] == '\n':
-			// If the last thing we wrote was multi-line,
-			// then we need to indent the next attribute.
-			f.buf = append(f.buf, indent...)
-		case f.buf[len(f.buf)-1] != ' ':
-			// All other attributes are separated by a space.
-			f.buf = append(f.buf, attrDelim...)
+	var elided bool
+	if f.repeats != nil && reader == nil {
+		key := groupedAttr{groups: groups, attr: attr}.fullKey()
+		if f.repeats.seen(key, valbs) {
+			valbs = append(valbs[:0], f.repeats.marker...)
+			forceMultiline = false
+			elided = true
 		}
 	}
 
+	if f.inlineMaxLen > 0 && !elided && ansi.StringWidth(string(valbs)) > f.inlineMaxLen {
+		// Long values are rendered like multi-line values.
+		forceMultiline = true
+	}
+
 	// Single-line attributes are rendered as:
 	//
 	//   key=value
@@ -405,55 +2560,534 @@ func (f *attrFormatter) FormatAttr(attr slog.Attr) {
 	//   key=
 	//     | line 1
 	//     | line 2
-	isMultiline := bytes.ContainsAny(valbs, "\r\n")
-	if isMultiline {
+	isMultiline := forceMultiline || bytes.ContainsAny(valbs, "\r\n")
+	f.multiline = f.multiline || isMultiline
+
+	keyGroups := groups
+	treeAttr := f.groupDepth > 0 && f.keyWidth == 0 && !f.flattenKeys && len(groups) > f.groupDepth
+	if f.groupBraces {
+		// Multi-line and tree attributes are written outside braces.
+		// Other attributes close braces for groups they're not in.
+		if isMultiline || treeAttr {
+			f.closeBraces(0)
+		} else {
+			for len(f.braces) > 0 && !isGroupPrefix(f.braced, groups) {
+				f.closeBraces(len(f.braces) - 1)
+			}
+		}
+	}
+
+	// lineIndent is the indentation of lines of this attribute.
+	// Tree attributes are indented below their branches.
+	lineIndent := indent
+	if treeAttr {
+		lineIndent = f.writeTreeBranches(f.keyStyle(groups), groups)
+		keyGroups = nil
+	} else if f.treePath != nil {
+		// The tree ends at the first attribute outside it.
+		if f.buf[len(f.buf)-1] != '\n' {
+			f.buf = append(f.buf, '\n')
+		}
+		f.treePath = nil
+	}
+
+	if f.keyWidth > 0 && len(f.buf) > 0 && f.buf[len(f.buf)-1] != '\n' && !bytes.HasSuffix(f.buf, newlineIndent) {
+		// Every attribute goes on its own line.
 		f.buf = append(f.buf, '\n')
-		f.buf = append(f.buf, indent...)
 	}
 
-	f.formatKey(attr.Key)
-	f.buf = append(f.buf, f.style.KeyValueDelimiter.Render()...) // =
+	// Add delimiter between attrs.
+	if len(f.buf) > 0 && !bytes.HasSuffix(f.buf, newlineIndent) {
+		// Multi-line attributes always start on a new line.
+		// If the previous attribute was multi-line,
+		// we're already on a new line.
+		if isMultiline && f.buf[len(f.buf)-1] != '\n' {
+			f.buf = append(f.buf, '\n')
+		}
+
+		switch {
+		case f.buf[len(f.buf)-1] == '\n':
+			// If the last thing we wrote was multi-line,
+			// or this attribute is multi-line,
+			// then we need to indent the attribute.
+			f.buf = append(f.buf, lineIndent...)
+		case f.buf[len(f.buf)-1] != ' ':
+			// All other attributes are separated by a delimiter.
+			f.buf = append(f.buf, f.attrDelim()...)
+		}
+	}
+
+	styles := f.attrStyles(groups, attr.Key)
+	if f.groupBraces && !isMultiline && !treeAttr {
+		keyGroups = nil
+		if rest := groups[len(f.braced):]; len(rest) > 0 {
+			f.openBrace(f.keyStyle(groups), rest)
+		}
+	}
+	if f.keyWidth > 0 {
+		if pad := f.keyWidth - f.keyTextWidth(keyGroups, attr.Key); pad > 0 {
+			f.buf = append(f.buf, strings.Repeat(" ", pad)...)
+		}
+	}
+	f.formatKey(styles.key, keyGroups, attr.Key)
+	f.buf = append(f.buf, styles.delim.Render()...) // =
 
-	valueStyle, hasStyle := f.style.Values[attr.Key]
 	if isMultiline {
-		prefixStyle := f.style.MultilineValuePrefix
-		if hasStyle {
-			prefixStyle = prefixStyle.Foreground(valueStyle.GetForeground())
+		prefix := lineIndent + styles.prefix.Render()
+		if f.keyWidth > 0 {
+			// Nest values below their aligned keys.
+			prefix = lineIndent + indent + styles.prefix.Render()
+		}
+
+		lines := valueLines(valbs)
+		if reader != nil {
+			lines = f.readerLines(reader)
 		}
-		prefix := indent + prefixStyle.Render()
 
-		// TODO: \r handling
 		f.buf = append(f.buf, '\n')
-		for line := range bytes.Lines(valbs) {
+		for line := range lines {
 			f.buf = append(f.buf, prefix...)
-			line = bytes.TrimRight(line, "\r\n")
-			if hasStyle {
-				f.buf = append(f.buf, valueStyle.Render(string(line))...)
-			} else {
-				f.buf = append(f.buf, line...)
-			}
+			f.buf = styles.appendValue(f.buf, line)
 			f.buf = append(f.buf, '\n')
 		}
 	} else {
-		if hasStyle {
-			f.buf = append(f.buf, valueStyle.Render(string(valbs))...)
+		f.buf = styles.appendValue(f.buf, valbs)
+		if bar, ok := f.style.ValueBars[attr.Key]; ok && !elided && value.Kind() == slog.KindDuration {
+			f.buf = f.appendValueBar(f.buf, bar, value.Duration())
+		}
+	}
+}
+
+// attrStyle holds the styles used to render one attribute.
+type attrStyle struct {
+	key    lipgloss.Style
+	delim  lipgloss.Style
+	prefix lipgloss.Style // multi-line values only
+
+	value    lipgloss.Style
+	hasValue bool // whether value is set
+}
+
+// attrStyles returns the styles for an attribute
+// with the given groups and key.
+//
+// Inline and multi-line values of the same attribute
+// use the same styles so that they look alike.
+// For attributes with a style in Style.Values,
+// the foreground color of the value is applied to
+// the key if colorKey is set,
+// the delimiter if colorDelimiter is set,
+// and the multi-line prefix unless neutralPrefix is set.
+func (f *attrFormatter) attrStyles(groups []string, key string) attrStyle {
+	s := attrStyle{
+		key:    f.keyStyle(groups),
+		prefix: f.style.MultilineValuePrefix,
+	}
+	if delim, ok := f.style.KeyValueDelimiters[key]; ok {
+		s.delim = delim
+	} else {
+		s.delim = f.style.KeyValueDelimiter
+	}
+
+	s.value, s.hasValue = f.valueStyle(key)
+	if !s.hasValue {
+		return s
+	}
+
+	fg := s.value.GetForeground()
+	if f.colorKey {
+		s.key = s.key.Foreground(fg)
+	}
+	if f.colorDelimiter {
+		s.delim = s.delim.Foreground(fg)
+	}
+	if !f.neutralPrefix {
+		s.prefix = s.prefix.Foreground(fg)
+	}
+	return s
+}
+
+// valueStyle returns the style for values with the given key
+// in records at the formatter's level.
+func (f *attrFormatter) valueStyle(key string) (lipgloss.Style, bool) {
+	if style, ok := f.style.ValuesByLevel[f.lvl][key]; ok {
+		return style, true
+	}
+	if style, ok := f.style.Values[key]; ok {
+		return style, true
+	}
+	return f.style.errorStyle(key)
+}
+
+// appendValue appends a rendered value to dst.
+func (s attrStyle) appendValue(dst, value []byte) []byte {
+	if !s.hasValue {
+		return append(dst, value...)
+	}
+	return append(dst, s.value.Render(string(value))...)
+}
+
+// writeMoreAttrs writes the marker for n omitted attributes
+// to the buffer.
+func (f *attrFormatter) writeMoreAttrs(n int) {
+	if f.keyWidth > 0 && f.buf[len(f.buf)-1] != '\n' && !bytes.HasSuffix(f.buf, newlineIndent) {
+		// Every attribute is on its own line.
+		f.buf = append(f.buf, '\n')
+	}
+	switch {
+	case bytes.HasSuffix(f.buf, newlineIndent):
+		// Already indented.
+	case f.buf[len(f.buf)-1] == '\n':
+		f.buf = append(f.buf, indent...)
+	case f.buf[len(f.buf)-1] != ' ':
+		f.buf = append(f.buf, f.attrDelim()...)
+	}
+	f.buf = append(f.buf, f.style.MoreAttrs.Render("…(+"+strconv.Itoa(n)+" more)")...)
+}
+
+// appendValueBar appends a bar for d to dst
+// if the bar has a maximum.
+func (f *attrFormatter) appendValueBar(dst []byte, bar ValueBar, d time.Duration) []byte {
+	if bar.Max <= 0 {
+		return dst
+	}
+	width := bar.Width
+	if width <= 0 {
+		width = 10
+	}
+
+	frac := float64(d) / float64(bar.Max)
+	filled := min(max(int(math.Round(frac*float64(width))), 0), width)
+
+	full, empty := "█", "░"
+	if f.asciiBars {
+		full, empty = "#", "-"
+	}
+	cells := strings.Repeat(full, filled) + strings.Repeat(empty, width-filled)
+
+	dst = append(dst, ' ')
+	if f.asciiBars {
+		dst = append(dst, cells...)
+	} else {
+		dst = append(dst, bar.Style.Render(cells)...)
+	}
+	dst = append(dst, ' ')
+	dst = strconv.AppendInt(dst, int64(math.Round(frac*100)), 10)
+	return append(dst, '%')
+}
+
+// appendValue appends the serialized form of a resolved value to dst.
+func (f *attrFormatter) appendValue(dst []byte, value slog.Value) []byte {
+	switch value.Kind() {
+	case slog.KindBool:
+		switch {
+		case !f.boolGlyphs:
+			dst = strconv.AppendBool(dst, value.Bool())
+		case value.Bool():
+			dst = append(dst, f.style.BoolGlyphs.True.Render()...)
+		default:
+			dst = append(dst, f.style.BoolGlyphs.False.Render()...)
+		}
+	case slog.KindDuration:
+		if f.durationFormat == DurationFormatAdaptive {
+			dst = appendAdaptiveDuration(dst, value.Duration())
 		} else {
-			f.buf = append(f.buf, valbs...)
+			dst = append(dst, value.Duration().String()...)
+		}
+	case slog.KindFloat64:
+		start := len(dst)
+		dst = strconv.AppendFloat(dst, value.Float64(), 'g', -1, 64)
+		dst = f.groupDigits(dst, start)
+	case slog.KindInt64:
+		start := len(dst)
+		dst = strconv.AppendInt(dst, value.Int64(), 10)
+		dst = f.groupDigits(dst, start)
+	case slog.KindString:
+		str := value.String()
+		if f.sanitizeUTF8 {
+			str = toValidUTF8(str)
+		}
+		if f.stripANSI {
+			str = ansi.Strip(str)
+		}
+		dst = append(dst, str...)
+	case slog.KindTime:
+		dst = value.Time().AppendFormat(dst, time.Kitchen)
+	case slog.KindUint64:
+		start := len(dst)
+		dst = strconv.AppendUint(dst, value.Uint64(), 10)
+		dst = f.groupDigits(dst, start)
+	default:
+		if isNil(value.Any()) {
+			return append(dst, f.nullValue()...)
+		}
+		if inner, valid, ok := unwrapNullable(value.Any()); ok {
+			if !valid {
+				return append(dst, f.nullValue()...)
+			}
+			return f.appendValue(dst, slog.AnyValue(inner))
+		}
+
+		start := len(dst)
+		joined := false
+		if f.sliceJoin != "" {
+			dst, joined = appendJoinedSlice(dst, value.Any(), f.sliceJoin)
+		}
+		if !joined {
+			dst = appendAnyValue(dst, value.Any(), f.anyFormat, f.verbose)
+		}
+		if f.sanitizeUTF8 && !utf8.Valid(dst[start:]) {
+			dst = append(dst[:start], bytes.ToValidUTF8(dst[start:], []byte(string(utf8.RuneError)))...)
+		}
+		if f.stripANSI {
+			dst = append(dst[:start], ansi.Strip(string(dst[start:]))...)
+		}
+	}
+	return dst
+}
+
+// groupDigits groups the digits of the number in dst[start:]
+// if requested.
+func (f *attrFormatter) groupDigits(dst []byte, start int) []byte {
+	if f.digitSep == "" {
+		return dst
+	}
+	num := bytes.Clone(dst[start:])
+	return appendGroupedDigits(dst[:start], num, f.digitSep)
+}
+
+// valueLines splits a multi-line value into lines.
+//
+// "\r\n", "\n", and a lone "\r" each end a line.
+// The yielded lines do not include line endings.
+// A line ending at the end of the value does not start a new line.
+func valueLines(bs []byte) iter.Seq[[]byte] {
+	return func(yield func([]byte) bool) {
+		for len(bs) > 0 {
+			idx := bytes.IndexAny(bs, "\r\n")
+			if idx < 0 {
+				yield(bs)
+				return
+			}
+
+			line, rest := bs[:idx], bs[idx+1:]
+			if bs[idx] == '\r' && len(rest) > 0 && rest[0] == '\n' {
+				rest = rest[1:]
+			}
+			if !yield(line) {
+				return
+			}
+			bs = rest
+		}
+	}
+}
+
+// appendAnyValue appends the text representation of an arbitrary value.
+// See the Handler documentation for the order of precedence.
+func appendAnyValue(bs []byte, v any, format AnyFormat, verbose bool) []byte {
+	switch v := v.(type) {
+	case slog.Source:
+		return appendSource(bs, &v)
+	case *slog.Source:
+		return appendSource(bs, v)
+	case fmt.Formatter:
+		if verbose {
+			return fmt.Appendf(bs, "%+v", v)
+		}
+		return fmt.Append(bs, v)
+	case fmt.Stringer, error:
+		return fmt.Append(bs, v)
+	case encoding.TextMarshaler:
+		if text, err := v.MarshalText(); err == nil {
+			return append(bs, text...)
+		}
+	}
+
+	if format == AnyFormatJSON && isComposite(v) {
+		if out, ok := appendJSON(bs, v); ok {
+			return out
+		}
+	}
+
+	// TODO: reflection to handle structs, maps, slices, etc.
+	return fmt.Append(bs, v)
+}
+
+// appendSource appends the location of a source as "file:line".
+func appendSource(bs []byte, src *slog.Source) []byte {
+	bs = append(bs, src.File...)
+	bs = append(bs, ':')
+	return strconv.AppendInt(bs, int64(src.Line), 10)
+}
+
+// isNil reports whether v is nil,
+// or a typed nil pointer, map, slice, channel, function, or interface.
+func isNil(v any) bool {
+	if v == nil {
+		return true
+	}
+
+	switch rv := reflect.ValueOf(v); rv.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice,
+		reflect.Chan, reflect.Func, reflect.Interface:
+		return rv.IsNil()
+	default:
+		return false
+	}
+}
+
+// nullValue returns the rendered value for nil attribute values.
+func (f *attrFormatter) nullValue() string {
+	if null := f.style.NullValue.Render(); null != "" {
+		return null
+	}
+	return "null"
+}
+
+// attrDelim returns the rendered delimiter between attributes.
+func (f *attrFormatter) attrDelim() string {
+	if delim := f.style.AttrDelimiter.Render(); delim != "" {
+		return delim
+	}
+	return attrDelim
+}
+
+// openBrace writes the given groups and an opening brace to the buffer
+// for attributes in those groups to follow.
+func (f *attrFormatter) openBrace(keyStyle lipgloss.Style, groups []string) {
+	for i, group := range groups {
+		if i > 0 {
+			f.buf = append(f.buf, groupDelim...)
+		}
+		f.buf = append(f.buf, keyStyle.Render(group)...)
+	}
+	f.buf = append(f.buf, '{')
+	f.braced = append(f.braced, groups...)
+	f.braces = append(f.braces, len(groups))
+}
+
+// writeTreeBranches starts a new line for an attribute
+// in the given groups that exceeds groupDepth,
+// writing the branches of the tree that it's in,
+// except those shared with the previous attribute.
+// It returns the indentation for the attribute.
+func (f *attrFormatter) writeTreeBranches(keyStyle lipgloss.Style, groups []string) string {
+	// The root branch holds the first groupDepth groups.
+	path := make([]string, 0, len(groups)-f.groupDepth+1)
+	path = append(path, strings.Join(groups[:f.groupDepth], groupDelim))
+	path = append(path, groups[f.groupDepth:]...)
+
+	if len(f.buf) > 0 && f.buf[len(f.buf)-1] != '\n' {
+		f.buf = append(f.buf, '\n')
+	}
+
+	shared := 0
+	for shared < len(f.treePath) && shared < len(path) && f.treePath[shared] == path[shared] {
+		shared++
+	}
+	for i, branch := range path[shared:] {
+		f.buf = append(f.buf, indent...)
+		f.buf = append(f.buf, strings.Repeat(indent, shared+i)...)
+		f.buf = append(f.buf, keyStyle.Render(branch)...)
+		f.buf = append(f.buf, ":\n"...)
+	}
+	f.treePath = path
+
+	return strings.Repeat(indent, len(path)+1)
+}
+
+// closeBraces closes open braces until n remain.
+func (f *attrFormatter) closeBraces(n int) {
+	for len(f.braces) > n {
+		last := f.braces[len(f.braces)-1]
+		f.braces = f.braces[:len(f.braces)-1]
+		f.braced = f.braced[:len(f.braced)-last]
+		f.buf = append(f.buf, '}')
+	}
+}
+
+// isGroupPrefix reports whether prefix is a prefix of groups.
+func isGroupPrefix(prefix, groups []string) bool {
+	return len(prefix) <= len(groups) && slices.Equal(prefix, groups[:len(prefix)])
+}
+
+// keyStyle returns the style for keys of attributes in the given groups.
+func (f *attrFormatter) keyStyle(groups []string) lipgloss.Style {
+	if len(f.style.GroupKeyStyles) == 0 || len(groups) == 0 {
+		return f.style.Key
+	}
+
+	if len(groups) > 1 {
+		if style, ok := f.style.GroupKeyStyles[strings.Join(groups, groupDelim)]; ok {
+			return style
+		}
+	}
+	if style, ok := f.style.GroupKeyStyles[groups[0]]; ok {
+		return style
+	}
+	return f.style.Key
+}
+
+// keyTextWidth returns the display width of the key
+// that formatKey writes for the given groups and key,
+// ignoring styles.
+func (f *attrFormatter) keyTextWidth(groups []string, key string) int {
+	width := ansi.StringWidth(key)
+	if f.flattenKeys {
+		return width
+	}
+	for _, group := range groups {
+		if group != "" {
+			width += ansi.StringWidth(group) + len(groupDelim)
 		}
 	}
+	return width
 }
 
 // formatKey writes a group-prefixed key to the buffer.
-func (f *attrFormatter) formatKey(key string) {
-	for _, group := range f.groups {
+// Groups are omitted if flattenKeys is set.
+func (f *attrFormatter) formatKey(keyStyle lipgloss.Style, groups []string, key string) {
+	if f.flattenKeys {
+		groups = nil
+	}
+	for _, group := range groups {
 		if group != "" {
-			f.buf = append(f.buf, f.style.Key.Render(group)...)
+			f.buf = append(f.buf, keyStyle.Render(group)...)
 			f.buf = append(f.buf, groupDelim...)
 		}
 	}
-	f.buf = append(f.buf, f.style.Key.Render(key)...)
+	f.buf = append(f.buf, keyStyle.Render(key)...)
+}
+
+// BufferPool is a pool of byte buffers
+// used by [Handler] to render log records.
+//
+// Get returns a buffer from the pool, allocating one if necessary.
+// The Handler ignores the existing contents of returned buffers.
+// Put returns a buffer to the pool after the Handler is done with it.
+//
+// Implementations must be safe for concurrent use.
+type BufferPool interface {
+	Get() *[]byte
+	Put(*[]byte)
 }
 
+// NoBufferPool is a [BufferPool] that does not pool buffers.
+// Get always allocates a new buffer, and Put discards it.
+//
+// Use it with HandlerOptions.BufferPool to disable buffer pooling.
+var NoBufferPool BufferPool = noBufferPool{}
+
+type noBufferPool struct{}
+
+func (noBufferPool) Get() *[]byte { return new([]byte) }
+
+func (noBufferPool) Put(*[]byte) {}
+
+// syncBufferPool is the default BufferPool.
+// It's backed by a package-level sync.Pool.
+type syncBufferPool struct{}
+
 var _bufPool = &sync.Pool{
 	New: func() any {
 		bs := make([]byte, 0, 1024)
@@ -461,12 +3095,16 @@ var _bufPool = &sync.Pool{
 	},
 }
 
-func takeBuf() *[]byte {
-	bs := _bufPool.Get().(*[]byte)
+func (syncBufferPool) Get() *[]byte { return _bufPool.Get().(*[]byte) }
+
+func (syncBufferPool) Put(bs *[]byte) { _bufPool.Put(bs) }
+
+func takeBuf(pool BufferPool) *[]byte {
+	bs := pool.Get()
 	*bs = (*bs)[:0]
 	return bs
 }
 
-func releaseBuf(bs *[]byte) {
-	_bufPool.Put(bs)
+func releaseBuf(pool BufferPool, bs *[]byte) {
+	pool.Put(bs)
 }
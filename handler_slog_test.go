@@ -18,7 +18,7 @@ func TestLogHandler_slogtest(t *testing.T) {
 		return NewHandler(&buffer, &HandlerOptions{
 			Level:      slog.LevelDebug,
 			Style:      PlainStyle(),
-			TimeFormat: time.RFC3339,
+			TimeFormat: TimeFormatISO,
 		})
 	}, func(t *testing.T) map[string]any {
 		attrs := make(map[string]any)
@@ -29,7 +29,7 @@ func TestLogHandler_slogtest(t *testing.T) {
 		require.True(t, ok, "missing time delimiter: %q", buffer.String())
 
 		var lvlstr string
-		if ts, err := time.Parse(time.RFC3339, timestr); err == nil {
+		if ts, err := time.Parse(TimeFormatISO, timestr); err == nil {
 			attrs[slog.TimeKey] = ts
 
 			lvlstr, line, ok = strings.Cut(line, lvlDelim)
@@ -1,15 +1,23 @@
 package silog_test
 
 import (
+	"context"
+	"errors"
 	"io"
 	"log/slog"
+	"os"
+	"path/filepath"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/colorprofile"
+	"github.com/charmbracelet/x/ansi"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.abhg.dev/log/silog"
 )
 
@@ -79,6 +87,15 @@ func TestHandler_formatting(t *testing.T) {
 			{"Time", someDate, "9:00PM"},
 			{"Uint64", uint64(42), "42"},
 			{"Stringer", &testStringer{"foo"}, "foo"},
+			{"Nil", nil, "null"},
+			{"NilPointer", (*testStringer)(nil), "null"},
+			{"NilInterface", error(nil), "null"},
+			{"NilMap", map[string]int(nil), "null"},
+			{"NilSlice", []string(nil), "null"},
+			{"EmptySlice", []string{}, "[]"},
+			{"TextMarshaler", testTextMarshaler{"foo"}, "text:foo"},
+			{"TextMarshalerError", testTextMarshaler{}, "{}"},
+			{"TextMarshalerStringer", testStringTextMarshaler{"foo"}, "string:foo"},
 		}
 
 		for _, tt := range tests {
@@ -227,6 +244,57 @@ func TestHandler_formatting(t *testing.T) {
 		)
 	})
 
+	t.Run("ConsecutiveMultilineAttrValues", func(t *testing.T) {
+		log.Info("foo", "k1", "bar\nbaz", "k2", "qux\nquux")
+		assertLinesWithTime(t,
+			"9:45AM INF foo  ",
+			"  k1=",
+			"    | bar",
+			"    | baz",
+			"  k2=",
+			"    | qux",
+			"    | quux",
+		)
+	})
+
+	t.Run("MultilineAttrValueLineEndings", func(t *testing.T) {
+		tests := []struct {
+			name  string
+			value string
+		}{
+			{"CRLF", "bar\r\nbaz\r\nqux"},
+			{"CR", "bar\rbaz\rqux"},
+			{"Mixed", "bar\r\nbaz\rqux\n"},
+			{"TrailingCRLF", "bar\nbaz\nqux\r\n"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				log.Info("foo", "k1", tt.value)
+				assertLinesWithTime(t,
+					"9:45AM INF foo  ",
+					"  k1=",
+					"    | bar",
+					"    | baz",
+					"    | qux",
+				)
+			})
+		}
+
+		t.Run("EmptyLines", func(t *testing.T) {
+			log.Info("foo", "k1", "bar\r\n\r\nbaz\r\rqux")
+			assertLinesWithTime(t,
+				"9:45AM INF foo  ",
+				"  k1=",
+				"    | bar",
+				"    | ",
+				"    | baz",
+				"    | ",
+				"    | qux",
+			)
+		})
+	})
+
 	t.Run("MultlineAttrValueNestedInGroup", func(t *testing.T) {
 		log := log.WithGroup("a").WithGroup("b")
 		log.Info("foo", slog.Group("c", "d", "foo\nbar\nbaz", "e", "qux"))
@@ -358,6 +426,55 @@ func TestHandler_WithLevel(t *testing.T) {
 	assert.Empty(t, buffer.String())
 }
 
+func TestHandler_WithLevelFunc(t *testing.T) {
+	type ctxKey struct{}
+
+	var buffer strings.Builder
+	handler := silog.NewHandler(&buffer, &silog.HandlerOptions{
+		Style:       silog.PlainStyle(),
+		ReplaceAttr: skipTime,
+	})
+
+	var gotLevels []slog.Level
+	handler = handler.WithLevelOffset(-4).WithLevelFunc(func(ctx context.Context, lvl slog.Level) bool {
+		gotLevels = append(gotLevels, lvl)
+		verbose, _ := ctx.Value(ctxKey{}).(bool)
+		return verbose || lvl >= slog.LevelWarn
+	})
+	log := slog.New(handler)
+
+	verboseCtx := context.WithValue(t.Context(), ctxKey{}, true)
+
+	log.InfoContext(t.Context(), "quiet")
+	log.InfoContext(verboseCtx, "verbose")
+	log.ErrorContext(t.Context(), "error")
+	log.DebugContext(verboseCtx, "debug")
+
+	assert.Equal(t,
+		"DBG verbose\n"+
+			"WRN error\n"+
+			"debug\n", // DEBUG-4 has no label
+		buffer.String())
+	assert.Contains(t, gotLevels, slog.LevelDebug, "level func should see offset levels")
+
+	t.Run("Handle", func(t *testing.T) {
+		buffer.Reset()
+
+		rec := slog.NewRecord(time.Time{}, slog.LevelInfo, "skipped", 0)
+		require.NoError(t, handler.Handle(t.Context(), rec))
+		assert.Empty(t, buffer.String())
+	})
+
+	t.Run("Reset", func(t *testing.T) {
+		buffer.Reset()
+
+		log := slog.New(handler.WithLevelFunc(nil))
+		log.Info("dropped")
+		log.Warn("static")
+		assert.Equal(t, "INF static\n", buffer.String())
+	})
+}
+
 func TestHandler_Enabled(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -466,6 +583,34 @@ func TestHandler_multilineMessageStyling(t *testing.T) {
 		buffer.String())
 }
 
+func TestHandler_indentContinuationLines(t *testing.T) {
+	var buffer strings.Builder
+	handler := silog.NewHandler(&buffer, &silog.HandlerOptions{
+		Style:                   silog.DefaultStyle(),
+		TimeFormat:              time.TimeOnly,
+		IndentContinuationLines: true,
+		MessageWidth:            12,
+		ReplaceAttr: func(groups []string, attr slog.Attr) slog.Attr {
+			if len(groups) == 0 && attr.Key == slog.TimeKey {
+				return slog.Time(slog.TimeKey, time.Date(2025, 1, 2, 15, 4, 5, 0, time.UTC))
+			}
+			return attr
+		},
+	})
+
+	log := slog.New(handler)
+	log.Error("panic: oops\ngoroutine 1\nmain.main()", "k", "v")
+	slog.New(handler.WithPrefix("app")).Info("foo\nbar")
+
+	assert.Equal(t, strings.Join([]string{
+		"15:04:05 ERR panic: oops",
+		"             goroutine 1",
+		"             main.main()   k=v",
+		"15:04:05 INF app: foo",
+		"                  bar",
+	}, "\n")+"\n", ansi.Strip(buffer.String()))
+}
+
 func TestHandler_attrValueStyle(t *testing.T) {
 	style := silog.PlainStyle()
 	style.Values["k1"] = lipgloss.NewStyle().Bold(true)
@@ -563,3 +708,2742 @@ func TestHandler_replaceTime_otherType(t *testing.T) {
 type testStringer struct{ v string }
 
 func (s *testStringer) String() string { return s.v }
+
+func TestHandler_alignPrefix(t *testing.T) {
+	style := silog.PlainStyle()
+	style.PrefixDelimiter = lipgloss.NewStyle().SetString(": ").Faint(true)
+
+	var buffer strings.Builder
+	handler := silog.NewHandler(&buffer, &silog.HandlerOptions{
+		Style:       style,
+		ReplaceAttr: skipTime,
+		AlignPrefix: true,
+		PrefixWidth: 5,
+	})
+
+	slog.New(handler).Info("foo")
+	slog.New(handler.WithPrefix("db")).Info("bar\nbaz")
+	slog.New(handler.WithPrefix("server")).Info("qux")
+
+	delim := style.PrefixDelimiter.Render()
+	assert.Equal(t,
+		"INF        foo\n"+
+			"INF db"+delim+"   bar\n"+
+			"INF db"+delim+"   baz\n"+
+			"INF server"+delim+"qux\n",
+		buffer.String())
+
+	t.Run("NoAlign", func(t *testing.T) {
+		buffer.Reset()
+
+		handler := silog.NewHandler(&buffer, &silog.HandlerOptions{
+			Style:       silog.PlainStyle(),
+			ReplaceAttr: skipTime,
+			PrefixWidth: 5,
+		})
+		slog.New(handler).Info("foo")
+		slog.New(handler.WithPrefix("db")).Info("bar")
+
+		assert.Equal(t, "INF foo\nINF db: bar\n", buffer.String())
+	})
+}
+
+func TestHandler_maxPrefixLen(t *testing.T) {
+	tests := []struct {
+		name   string
+		prefix string
+		max    int
+		want   string
+	}{
+		{name: "NoLimit", prefix: "database", max: 0, want: "database"},
+		{name: "NegativeLimit", prefix: "database", max: -1, want: "database"},
+		{name: "Shorter", prefix: "database", max: 9, want: "database"},
+		{name: "Exact", prefix: "database", max: 8, want: "database"},
+		{name: "OneOver", prefix: "database", max: 7, want: "dat…ase"},
+		{name: "Even", prefix: "database", max: 6, want: "dat…se"},
+		{name: "Odd", prefix: "database", max: 5, want: "da…se"},
+		{name: "Two", prefix: "database", max: 2, want: "d…"},
+		{name: "One", prefix: "database", max: 1, want: "…"},
+		{name: "Runes", prefix: "日本語のデータベース", max: 5, want: "日本…ース"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buffer strings.Builder
+			handler := silog.NewHandler(&buffer, &silog.HandlerOptions{
+				Style:        silog.PlainStyle(),
+				ReplaceAttr:  skipTime,
+				MaxPrefixLen: tt.max,
+			})
+
+			slog.New(handler.WithPrefix(tt.prefix)).Info("foo\nbar")
+			assert.Equal(t,
+				"INF "+tt.want+": foo\n"+
+					"INF "+tt.want+": bar\n",
+				buffer.String())
+		})
+	}
+}
+
+func TestHandler_prefixFormat(t *testing.T) {
+	colon := lipgloss.NewStyle().SetString(" - ")
+	angle := lipgloss.NewStyle().SetString("<")
+	tests := []struct {
+		name      string
+		overrides silog.StyleOverrides
+		format    silog.PrefixFormat
+		want      string
+	}{
+		{
+			name: "Delimited",
+			want: "INF db: foo\nINF db: bar  k=v\n",
+		},
+		{
+			name:      "DelimitedCustom",
+			overrides: silog.StyleOverrides{PrefixDelimiter: &colon},
+			want:      "INF db - foo\nINF db - bar  k=v\n",
+		},
+		{
+			name:   "Bracketed",
+			format: silog.PrefixFormatBracketed,
+			want:   "INF [db] foo\nINF [db] bar  k=v\n",
+		},
+		{
+			name:      "BracketedCustom",
+			overrides: silog.StyleOverrides{PrefixOpen: &angle},
+			format:    silog.PrefixFormatBracketed,
+			want:      "INF <db] foo\nINF <db] bar  k=v\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			style := silog.PlainStyle().With(tt.overrides)
+			style.PrefixFormat = tt.format
+
+			var buffer strings.Builder
+			handler := silog.NewHandler(&buffer, &silog.HandlerOptions{
+				Style:       style,
+				ReplaceAttr: skipTime,
+			}).WithPrefix("db")
+			slog.New(handler).Info("foo\nbar", "k", "v")
+
+			assert.Equal(t, tt.want, buffer.String())
+			assert.Equal(t, "db", handler.Prefix())
+		})
+	}
+
+	t.Run("AlignPrefix", func(t *testing.T) {
+		style := silog.PlainStyle()
+		style.PrefixFormat = silog.PrefixFormatBracketed
+
+		var buffer strings.Builder
+		handler := silog.NewHandler(&buffer, &silog.HandlerOptions{
+			Style:       style,
+			ReplaceAttr: skipTime,
+			AlignPrefix: true,
+			PrefixWidth: 4,
+		})
+		slog.New(handler.WithPrefix("db")).Info("foo")
+		slog.New(handler.WithPrefix("http")).Info("bar")
+		slog.New(handler).Info("baz")
+
+		assert.Equal(t,
+			"INF [db]   foo\n"+
+				"INF [http] bar\n"+
+				"INF        baz\n",
+			buffer.String())
+	})
+}
+
+func TestHandler_prefixFromContext(t *testing.T) {
+	type subsystemKey struct{}
+
+	style := silog.PlainStyle()
+	style.PrefixDelimiter = lipgloss.NewStyle().SetString(" | ")
+
+	var buffer strings.Builder
+	handler := silog.NewHandler(&buffer, &silog.HandlerOptions{
+		Style:       style,
+		ReplaceAttr: skipTime,
+		PrefixFromContext: func(ctx context.Context) string {
+			name, _ := ctx.Value(subsystemKey{}).(string)
+			return name
+		},
+	})
+
+	ctx := context.WithValue(t.Context(), subsystemKey{}, "auth")
+	slog.New(handler).InfoContext(ctx, "foo\nbar")
+	slog.New(handler).InfoContext(t.Context(), "no prefix")
+	slog.New(handler.WithPrefix("static")).InfoContext(ctx, "overridden")
+	slog.New(handler.WithPrefix("static")).InfoContext(t.Context(), "not overridden")
+
+	assert.Equal(t,
+		"INF auth | foo\n"+
+			"INF auth | bar\n"+
+			"INF no prefix\n"+
+			"INF auth | overridden\n"+
+			"INF static | not overridden\n",
+		buffer.String())
+}
+
+func TestHandler_levelWriter(t *testing.T) {
+	var out levelRecorder
+	handler := silog.NewHandler(&out, &silog.HandlerOptions{
+		Level:       slog.LevelDebug,
+		Style:       silog.PlainStyle(),
+		ReplaceAttr: skipTime,
+	})
+
+	log := slog.New(handler.WithLevelOffset(-4))
+	log.Info("foo")
+	log.Error("bar")
+
+	assert.Equal(t, []slog.Level{slog.LevelDebug, slog.LevelWarn}, out.levels)
+	assert.Equal(t, []string{"DBG foo\n", "WRN bar\n"}, out.lines)
+}
+
+type levelRecorder struct {
+	levels []slog.Level
+	lines  []string
+}
+
+var _ silog.LevelWriter = (*levelRecorder)(nil)
+
+func (*levelRecorder) Write([]byte) (int, error) {
+	panic("Write should not be called on a LevelWriter")
+}
+
+func (r *levelRecorder) WriteLevel(lvl slog.Level, p []byte) (int, error) {
+	r.levels = append(r.levels, lvl)
+	r.lines = append(r.lines, string(p))
+	return len(p), nil
+}
+
+func TestHandler_defaultAttrs(t *testing.T) {
+	var buffer strings.Builder
+	handler := silog.NewHandler(&buffer, &silog.HandlerOptions{
+		Style: silog.PlainStyle(),
+		DefaultAttrs: []slog.Attr{
+			slog.String("service", "api"),
+			slog.Group("build", slog.String("version", "1.2.3")),
+			slog.String("secret", "hunter2"),
+		},
+		ReplaceAttr: func(groups []string, attr slog.Attr) slog.Attr {
+			if attr.Key == "secret" {
+				return slog.String(attr.Key, "REDACTED")
+			}
+			return skipTime(groups, attr)
+		},
+	})
+	log := slog.New(handler)
+
+	log.Info("foo")
+	log.With("k1", 1).WithGroup("g").With("k2", 2).Info("bar", "k3", 3)
+
+	assert.Equal(t,
+		"INF foo  service=api build.version=1.2.3 secret=REDACTED\n"+
+			"INF bar  service=api build.version=1.2.3 secret=REDACTED k1=1 g.k2=2 g.k3=3\n",
+		buffer.String())
+}
+
+func TestHandler_attrsOnNewLine(t *testing.T) {
+	var buffer strings.Builder
+	handler := silog.NewHandler(&buffer, &silog.HandlerOptions{
+		Style:          silog.PlainStyle(),
+		ReplaceAttr:    skipTime,
+		AttrsOnNewLine: true,
+	})
+	log := slog.New(handler)
+
+	assertLines := func(t *testing.T, lines ...string) {
+		t.Helper()
+
+		assert.Equal(t, strings.Join(lines, "\n")+"\n", buffer.String())
+		buffer.Reset()
+	}
+
+	t.Run("NoAttrs", func(t *testing.T) {
+		log.Info("foo")
+		assertLines(t, "INF foo")
+	})
+
+	t.Run("Attrs", func(t *testing.T) {
+		log.Info("foo", "k1", 1, "k2", 2)
+		assertLines(t,
+			"INF foo",
+			"  k1=1 k2=2",
+		)
+	})
+
+	t.Run("WithAttrs", func(t *testing.T) {
+		log.With("k1", 1).Info("foo", "k2", 2)
+		assertLines(t,
+			"INF foo",
+			"  k1=1 k2=2",
+		)
+	})
+
+	t.Run("MultilineMessage", func(t *testing.T) {
+		log.Info("foo\nbar", "k1", 1)
+		assertLines(t,
+			"INF foo",
+			"INF bar",
+			"  k1=1",
+		)
+	})
+
+	t.Run("TrailingNewlineMessage", func(t *testing.T) {
+		log.Info("foo\n", "k1", 1)
+		assertLines(t,
+			"INF foo",
+			"  k1=1",
+		)
+	})
+
+	t.Run("MultilineAttr", func(t *testing.T) {
+		log.Info("foo", "k1", "bar\nbaz", "k2", 2)
+		assertLines(t,
+			"INF foo",
+			"  k1=",
+			"    | bar",
+			"    | baz",
+			"  k2=2",
+		)
+	})
+
+	t.Run("WithMultilineAttr", func(t *testing.T) {
+		log.With("k1", "bar\nbaz").Info("foo", "k2", 2)
+		assertLines(t,
+			"INF foo",
+			"  k1=",
+			"    | bar",
+			"    | baz",
+			"  k2=2",
+		)
+	})
+}
+
+func TestHandler_inlineGroups(t *testing.T) {
+	tests := []struct {
+		name string
+		give []any
+		want string
+	}{
+		{
+			name: "Empty",
+			give: []any{slog.Group("", "k", 1)},
+			want: "k=1",
+		},
+		{
+			name: "NestedEmpty",
+			give: []any{slog.Group("", slog.Group("", "k", 1))},
+			want: "k=1",
+		},
+		{
+			name: "EmptyInNamed",
+			give: []any{slog.Group("a", slog.Group("", "k", 1), "j", 2)},
+			want: "a.k=1 a.j=2",
+		},
+		{
+			name: "NamedInEmpty",
+			give: []any{slog.Group("", slog.Group("a", "k", 1))},
+			want: "a.k=1",
+		},
+		{
+			name: "NamedInEmptyInNamed",
+			give: []any{slog.Group("a", slog.Group("", slog.Group("b", "k", 1)))},
+			want: "a.b.k=1",
+		},
+		{
+			name: "NoMembers",
+			give: []any{slog.Group("", slog.Group("")), "k", 1},
+			want: "k=1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Record the group paths seen by ReplaceAttr,
+			// and compare them against slog.TextHandler.
+			var gotGroups, wantGroups []string
+			recordGroups := func(dst *[]string) func([]string, slog.Attr) slog.Attr {
+				return func(groups []string, attr slog.Attr) slog.Attr {
+					if len(groups) == 0 && attr.Key == slog.TimeKey {
+						return slog.Attr{}
+					}
+					if attr.Value.Kind() != slog.KindGroup && len(groups) > 0 {
+						*dst = append(*dst, strings.Join(groups, "/")+"/"+attr.Key)
+					}
+					return attr
+				}
+			}
+
+			var buffer strings.Builder
+			slog.New(silog.NewHandler(&buffer, &silog.HandlerOptions{
+				Style:       silog.PlainStyle(),
+				ReplaceAttr: recordGroups(&gotGroups),
+			})).Info("msg", tt.give...)
+
+			var text strings.Builder
+			slog.New(slog.NewTextHandler(&text, &slog.HandlerOptions{
+				ReplaceAttr: recordGroups(&wantGroups),
+			})).Info("msg", tt.give...)
+
+			assert.Equal(t, "INF msg  "+tt.want+"\n", buffer.String())
+			assert.Equal(t, "level=INFO msg=msg "+tt.want+"\n", text.String(),
+				"slog.TextHandler output")
+			assert.Equal(t, wantGroups, gotGroups, "ReplaceAttr groups")
+		})
+	}
+}
+
+func TestHandler_withAttrsGroup(t *testing.T) {
+	tests := []struct {
+		name  string
+		build func(*slog.Logger) *slog.Logger
+		give  []any
+		want  string
+	}{
+		{
+			name: "Group",
+			build: func(log *slog.Logger) *slog.Logger {
+				return log.With(slog.Group("g", "k", 1))
+			},
+			give: []any{"a", 2},
+			want: "g.k=1 a=2",
+		},
+		{
+			name: "GroupThenWithGroup",
+			build: func(log *slog.Logger) *slog.Logger {
+				return log.With(slog.Group("g", "k", 1)).WithGroup("h")
+			},
+			give: []any{"a", 2, slog.Group("i", "b", 3)},
+			want: "g.k=1 h.a=2 h.i.b=3",
+		},
+		{
+			name: "WithGroupThenGroup",
+			build: func(log *slog.Logger) *slog.Logger {
+				return log.WithGroup("h").With(slog.Group("g", "k", 1))
+			},
+			give: []any{"a", 2},
+			want: "h.g.k=1 h.a=2",
+		},
+		{
+			name: "Interleaved",
+			build: func(log *slog.Logger) *slog.Logger {
+				return log.
+					WithGroup("a").
+					With(slog.Group("g", "k", 1), "x", 2).
+					WithGroup("b").
+					With(slog.Group("g", slog.Group("h", "k", 3)))
+			},
+			give: []any{"y", 4},
+			want: "a.g.k=1 a.x=2 a.b.g.h.k=3 a.b.y=4",
+		},
+		{
+			name: "InlineGroup",
+			build: func(log *slog.Logger) *slog.Logger {
+				return log.WithGroup("a").With(slog.Group("", "k", 1))
+			},
+			give: []any{"y", 2},
+			want: "a.k=1 a.y=2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, together := range []bool{false, true} {
+				var buffer strings.Builder
+				tt.build(slog.New(silog.NewHandler(&buffer, &silog.HandlerOptions{
+					Style:              silog.PlainStyle(),
+					ReplaceAttr:        skipTime,
+					GroupAttrsTogether: together,
+				}))).Info("msg", tt.give...)
+				assert.Equal(t, "INF msg  "+tt.want+"\n", buffer.String(),
+					"GroupAttrsTogether=%v", together)
+			}
+
+			var text strings.Builder
+			tt.build(slog.New(slog.NewTextHandler(&text, &slog.HandlerOptions{
+				ReplaceAttr: skipTime,
+			}))).Info("msg", tt.give...)
+			assert.Equal(t, "level=INFO msg=msg "+tt.want+"\n", text.String(),
+				"slog.TextHandler output")
+		})
+	}
+}
+
+func TestHandler_neutralMultilinePrefix(t *testing.T) {
+	red := lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+	faint := lipgloss.NewStyle().Faint(true)
+
+	style := silog.PlainStyle()
+	style.MultilineValuePrefix = faint.SetString("|")
+	style.Values["error"] = red
+
+	tests := []struct {
+		name    string
+		neutral bool
+		prefix  string
+	}{
+		{name: "Default", prefix: faint.Foreground(red.GetForeground()).Render("|")},
+		{name: "Neutral", neutral: true, prefix: faint.Render("|")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buffer strings.Builder
+			handler := silog.NewHandler(&buffer, &silog.HandlerOptions{
+				Style:                  style,
+				ReplaceAttr:            skipTime,
+				NeutralMultilinePrefix: tt.neutral,
+			})
+
+			slog.New(handler).Error("failed", "error", "foo\nbar", "out", "baz\nqux")
+			assert.Equal(t,
+				"ERR failed  \n"+
+					"  error=\n"+
+					"  "+tt.prefix+red.Render("foo")+"\n"+
+					"  "+tt.prefix+red.Render("bar")+"\n"+
+					"  out=\n"+
+					"  "+faint.Render("|")+"baz\n"+
+					"  "+faint.Render("|")+"qux\n",
+				buffer.String())
+		})
+	}
+}
+
+func TestHandler_keyValueDelimiters(t *testing.T) {
+	style := silog.PlainStyle()
+	style.KeyValueDelimiters = map[string]lipgloss.Style{
+		"status": lipgloss.NewStyle().SetString(": "),
+		"note":   lipgloss.NewStyle().SetString(" -> "),
+	}
+
+	var buffer strings.Builder
+	handler := silog.NewHandler(&buffer, &silog.HandlerOptions{
+		Style: style,
+		ReplaceAttr: func(groups []string, attr slog.Attr) slog.Attr {
+			if len(groups) == 0 && attr.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			if attr.Key == "code" {
+				attr.Key = "status"
+			}
+			return attr
+		},
+	})
+
+	slog.New(handler).Info("foo",
+		"status", 200,
+		"path", "/",
+		slog.Group("req", "note", "hi"),
+		"code", 404,
+	)
+	assert.Equal(t,
+		"INF foo  status: 200 path=/ req.note -> hi status: 404\n",
+		buffer.String())
+}
+
+func TestHandler_groupKeyStyles(t *testing.T) {
+	blue := lipgloss.NewStyle().Foreground(lipgloss.Color("4"))
+	magenta := lipgloss.NewStyle().Foreground(lipgloss.Color("5"))
+	bold := lipgloss.NewStyle().Bold(true)
+
+	style := silog.PlainStyle()
+	style.GroupKeyStyles = map[string]lipgloss.Style{
+		"db":      blue,
+		"db.pool": bold,
+		"cache":   magenta,
+	}
+
+	var buffer strings.Builder
+	handler := silog.NewHandler(&buffer, &silog.HandlerOptions{
+		Style:       style,
+		ReplaceAttr: skipTime,
+	})
+
+	slog.New(handler).Info("foo",
+		"k", 1,
+		slog.Group("db", "name", "main", slog.Group("pool", "size", 2), slog.Group("tx", "id", 3)),
+		slog.Group("cache", "hit", true),
+	)
+	assert.Equal(t,
+		"INF foo  k=1 "+
+			blue.Render("db")+"."+blue.Render("name")+"=main "+
+			bold.Render("db")+"."+bold.Render("pool")+"."+bold.Render("size")+"=2 "+
+			blue.Render("db")+"."+blue.Render("tx")+"."+blue.Render("id")+"=3 "+
+			magenta.Render("cache")+"."+magenta.Render("hit")+"=true\n",
+		buffer.String())
+}
+
+func TestHandler_dedupGroups(t *testing.T) {
+	tests := []struct {
+		name  string
+		dedup bool
+		want  string
+	}{
+		{
+			name: "Disabled",
+			want: "INF foo  a.a.k1=1 a.a.b.a.a.k2=2\n",
+		},
+		{
+			name:  "Enabled",
+			dedup: true,
+			want:  "INF foo  a.k1=1 a.b.a.k2=2\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buffer strings.Builder
+			log := slog.New(silog.NewHandler(&buffer, &silog.HandlerOptions{
+				Style:       silog.PlainStyle(),
+				ReplaceAttr: skipTime,
+				DedupGroups: tt.dedup,
+			}))
+
+			log.WithGroup("a").WithGroup("a").
+				With("k1", 1).
+				WithGroup("b").WithGroup("a").WithGroup("a").
+				Info("foo", "k2", 2)
+			assert.Equal(t, tt.want, buffer.String())
+		})
+	}
+}
+
+func TestHandler_lineStyle(t *testing.T) {
+	style := silog.PlainStyle()
+	style.Lines = map[slog.Level]lipgloss.Style{
+		slog.LevelError: lipgloss.NewStyle().Foreground(lipgloss.Color("1")),
+	}
+	style.Values["error"] = lipgloss.NewStyle().Bold(true)
+
+	var buffer strings.Builder
+	log := slog.New(silog.NewHandler(&buffer, &silog.HandlerOptions{
+		Style:       style,
+		ReplaceAttr: skipTime,
+	}))
+
+	log.Info("foo", "k", "v")
+	log.Error("bar\nbaz", "error", "qux")
+
+	assert.Equal(t,
+		"INF foo  k=v\n"+
+			"\x1b[31mERR bar\x1b[m\n"+
+			"\x1b[31mERR baz  error=\x1b[1mqux\x1b[m\x1b[31m\x1b[m\n",
+		buffer.String())
+}
+
+func TestHandler_attrsContainer(t *testing.T) {
+	style := silog.PlainStyle()
+	style.AttrsContainer = lipgloss.NewStyle().Background(lipgloss.Color("0"))
+	style.Values["error"] = lipgloss.NewStyle().Bold(true)
+
+	var buffer strings.Builder
+	log := slog.New(silog.NewHandler(&buffer, &silog.HandlerOptions{
+		Style:       style,
+		ReplaceAttr: skipTime,
+	}))
+
+	log.Info("foo")
+	log.Info("bar", "k", "v", "error", "qux")
+	log.Info("baz", "k", "v", "out", "a\nb")
+	log.Info("qux", "out", "c")
+	log.Info("quux", "out", "d\ne")
+
+	assert.Equal(t,
+		"INF foo\n"+
+			"INF bar  \x1b[40mk=v error=\x1b[1mqux\x1b[m\x1b[40m\x1b[m\n"+
+			"INF baz  \x1b[40mk=v\x1b[m\n"+
+			"\x1b[40m  out=\x1b[m\n"+
+			"\x1b[40m    | a\x1b[m\n"+
+			"\x1b[40m    | b\x1b[m\n"+
+			"INF qux  \x1b[40mout=c\x1b[m\n"+
+			"INF quux  \n"+
+			"\x1b[40m  out=\x1b[m\n"+
+			"\x1b[40m    | d\x1b[m\n"+
+			"\x1b[40m    | e\x1b[m\n",
+		buffer.String())
+}
+
+func TestHandler_boolGlyphs(t *testing.T) {
+	green := lipgloss.NewStyle().Foreground(lipgloss.Color("2"))
+
+	style := silog.PlainStyle()
+	style.BoolGlyphs = silog.BoolGlyphs{
+		True:  green.SetString("✓"),
+		False: lipgloss.NewStyle().SetString("✗"),
+	}
+
+	tests := []struct {
+		name    string
+		profile colorprofile.Profile
+		format  silog.Format
+		want    string
+	}{
+		{
+			name: "Default",
+			want: "INF msg  ok=" + green.Render("✓") + " failed=✗ s=true\n",
+		},
+		{
+			name:    "Ascii",
+			profile: colorprofile.Ascii,
+			want:    "INF msg  ok=true failed=false s=true\n",
+		},
+		{
+			name:    "NoTTY",
+			profile: colorprofile.NoTTY,
+			want:    "INF msg  ok=true failed=false s=true\n",
+		},
+		{
+			name:   "TSV",
+			format: silog.FormatTSV,
+			want:   "\tINF\t\tmsg\tok=true failed=false s=true\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buffer strings.Builder
+			handler := silog.NewHandler(&buffer, &silog.HandlerOptions{
+				Style:        style,
+				ReplaceAttr:  skipTime,
+				ColorProfile: tt.profile,
+				Format:       tt.format,
+			})
+
+			slog.New(handler).Info("msg", "ok", true, "failed", false, "s", "true")
+			assert.Equal(t, tt.want, buffer.String())
+		})
+	}
+
+	t.Run("Unset", func(t *testing.T) {
+		style := silog.PlainStyle()
+		style.BoolGlyphs.True = lipgloss.NewStyle().SetString("✓")
+
+		var buffer strings.Builder
+		handler := silog.NewHandler(&buffer, &silog.HandlerOptions{
+			Style:       style,
+			ReplaceAttr: skipTime,
+		})
+
+		slog.New(handler).Info("msg", "ok", true)
+		assert.Equal(t, "INF msg  ok=true\n", buffer.String())
+	})
+}
+
+func TestHandler_baseIndent(t *testing.T) {
+	style := silog.PlainStyle()
+	style.Lines = map[slog.Level]lipgloss.Style{
+		slog.LevelError: lipgloss.NewStyle().Bold(true),
+	}
+
+	var buffer strings.Builder
+	handler := silog.NewHandler(&buffer, &silog.HandlerOptions{
+		Style:                   style,
+		ReplaceAttr:             skipTime,
+		BaseIndent:              "    ",
+		IndentContinuationLines: true,
+	})
+
+	log := slog.New(handler)
+	log.Info("foo", "k", "v")
+	log.Info("bar\nbaz", "out", "a\n\nb")
+	log.Error("qux")
+
+	assert.Equal(t,
+		"    INF foo  k=v\n"+
+			"    INF bar\n"+
+			"        baz  \n"+
+			"      out=\n"+
+			"        | a\n"+
+			"        | \n"+
+			"        | b\n"+
+			"    \x1b[1mERR qux\x1b[m\n",
+		buffer.String())
+}
+
+func TestHandler_recursiveLogValuer(t *testing.T) {
+	var buffer strings.Builder
+	log := slog.New(silog.NewHandler(&buffer, &silog.HandlerOptions{
+		Style:       silog.PlainStyle(),
+		ReplaceAttr: skipTime,
+	}))
+
+	t.Run("Self", func(t *testing.T) {
+		defer buffer.Reset()
+
+		log.Info("foo", "k", selfValuer{})
+		assert.Regexp(t, `^INF foo  k=LogValue called too many times.*\n$`, buffer.String())
+	})
+
+	t.Run("Group", func(t *testing.T) {
+		defer buffer.Reset()
+
+		log.Info("foo", "k", groupValuer{})
+		out := buffer.String()
+		assert.Contains(t, out, "exceeded maximum group depth")
+		assert.Contains(t, out, "k.x=1 k.k.x=1")
+		assert.Equal(t, 1, strings.Count(out, "\n"))
+	})
+}
+
+// selfValuer is a LogValuer that resolves to itself.
+type selfValuer struct{}
+
+func (v selfValuer) LogValue() slog.Value { return slog.AnyValue(v) }
+
+// groupValuer is a LogValuer that resolves to a group containing itself.
+type groupValuer struct{}
+
+func (v groupValuer) LogValue() slog.Value {
+	return slog.GroupValue(slog.Int("x", 1), slog.Any("k", v))
+}
+
+func TestDiscardHandler(t *testing.T) {
+	handler := silog.DiscardHandler()
+
+	assert.False(t, handler.Enabled(t.Context(), slog.LevelError+100))
+	require.NoError(t, handler.Handle(t.Context(),
+		slog.NewRecord(time.Now(), slog.LevelError, "foo", 0)))
+
+	derived := handler.
+		WithLevel(slog.LevelDebug).
+		WithLevelFunc(func(context.Context, slog.Level) bool { return true }).
+		WithPrefix("prefix").
+		WithAttrs([]slog.Attr{slog.String("k", "v")}).
+		WithGroup("g")
+	assert.False(t, derived.Enabled(t.Context(), slog.LevelError))
+
+	// Should not panic.
+	slog.New(derived).Error("foo", "k", "v")
+}
+
+func TestHandler_attrDelimiter(t *testing.T) {
+	style := silog.PlainStyle()
+	style.AttrDelimiter = lipgloss.NewStyle().SetString(", ").Faint(true)
+	delim := style.AttrDelimiter.Render()
+
+	var buffer strings.Builder
+	log := slog.New(silog.NewHandler(&buffer, &silog.HandlerOptions{
+		Style:       style,
+		ReplaceAttr: skipTime,
+	}))
+
+	log.With("k1", 1).Info("foo", "k2", 2, "k3", "bar\nbaz", "k4", 4, "k5", 5)
+	assert.Equal(t,
+		"INF foo  k1=1"+delim+"k2=2\n"+
+			"  k3=\n"+
+			"    | bar\n"+
+			"    | baz\n"+
+			"  k4=4"+delim+"k5=5\n",
+		buffer.String())
+
+	t.Run("Unset", func(t *testing.T) {
+		buffer.Reset()
+
+		style := silog.PlainStyle()
+		style.AttrDelimiter = lipgloss.NewStyle()
+		log := slog.New(silog.NewHandler(&buffer, &silog.HandlerOptions{
+			Style:       style,
+			ReplaceAttr: skipTime,
+		}))
+
+		log.Info("foo", "k1", 1, "k2", 2)
+		assert.Equal(t, "INF foo  k1=1 k2=2\n", buffer.String())
+	})
+}
+
+func TestHandler_bufferPool(t *testing.T) {
+	t.Run("Custom", func(t *testing.T) {
+		var pool countingPool
+		var buffer strings.Builder
+		log := slog.New(silog.NewHandler(&buffer, &silog.HandlerOptions{
+			Style:       silog.PlainStyle(),
+			ReplaceAttr: skipTime,
+			BufferPool:  &pool,
+		}))
+
+		log.Info("foo", "k1", 1, "k2", "bar\nbaz")
+		assert.Equal(t, "INF foo  k1=1\n  k2=\n    | bar\n    | baz\n", buffer.String())
+		assert.Positive(t, pool.gets)
+		assert.Equal(t, pool.gets, pool.puts, "all buffers should be returned")
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		var buffer strings.Builder
+		log := slog.New(silog.NewHandler(&buffer, &silog.HandlerOptions{
+			Style:       silog.PlainStyle(),
+			ReplaceAttr: skipTime,
+			BufferPool:  silog.NoBufferPool,
+		}))
+
+		log.Info("foo", "k", "v")
+		log.Info("bar")
+		assert.Equal(t, "INF foo  k=v\nINF bar\n", buffer.String())
+	})
+}
+
+type countingPool struct {
+	gets, puts int
+}
+
+func (p *countingPool) Get() *[]byte {
+	p.gets++
+	bs := []byte("garbage")
+	return &bs
+}
+
+func (p *countingPool) Put(*[]byte) {
+	p.puts++
+}
+
+func TestHandler_timeFormatISO(t *testing.T) {
+	zone := time.FixedZone("PST", -8*60*60)
+	want := time.Date(2025, 1, 2, 15, 4, 5, 678_000_000, zone)
+
+	var buffer strings.Builder
+	handler := silog.NewHandler(&buffer, &silog.HandlerOptions{
+		Style:      silog.PlainStyle(),
+		TimeFormat: silog.TimeFormatISO,
+		ReplaceAttr: func(groups []string, attr slog.Attr) slog.Attr {
+			if len(groups) == 0 && attr.Key == slog.TimeKey {
+				return slog.Time(slog.TimeKey, want)
+			}
+			return attr
+		},
+	})
+	slog.New(handler).Info("foo")
+
+	timestr, _, ok := strings.Cut(buffer.String(), " ")
+	require.True(t, ok, "missing time delimiter: %q", buffer.String())
+	assert.Equal(t, "2025-01-02T15:04:05.678-08:00", timestr)
+
+	got, err := time.Parse(silog.TimeFormatISO, timestr)
+	require.NoError(t, err)
+	assert.True(t, want.Equal(got), "want %v, got %v", want, got)
+}
+
+func TestHandler_levelTimeFormats(t *testing.T) {
+	when := time.Date(2025, 1, 2, 15, 4, 5, 678_000_000, time.UTC)
+
+	var buffer strings.Builder
+	handler := silog.NewHandler(&buffer, &silog.HandlerOptions{
+		Style: silog.PlainStyle(),
+		Level: slog.LevelDebug,
+		LevelTimeFormats: map[slog.Level]string{
+			slog.LevelDebug: silog.TimeFormatMillis,
+			slog.LevelError: time.DateTime,
+		},
+		// The layout applies to times returned by ReplaceAttr.
+		ReplaceAttr: func(groups []string, attr slog.Attr) slog.Attr {
+			if len(groups) == 0 && attr.Key == slog.TimeKey {
+				return slog.Time(slog.TimeKey, when)
+			}
+			return attr
+		},
+	})
+	log := slog.New(handler)
+	log.Debug("foo")
+	log.Info("bar")
+	log.Error("baz")
+
+	assert.Equal(t, strings.Join([]string{
+		"15:04:05.678 DBG foo",
+		"3:04PM INF bar",
+		"2025-01-02 15:04:05 ERR baz",
+	}, "\n")+"\n", buffer.String())
+}
+
+func TestHandler_valueBars(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile colorprofile.Profile
+		give    time.Duration
+		want    string
+	}{
+		{name: "Partial", give: 800 * time.Millisecond, want: "latency=800ms ████░ 80%"},
+		{name: "Empty", give: 0, want: "latency=0s ░░░░░ 0%"},
+		{name: "Overflow", give: 2 * time.Second, want: "latency=2s █████ 200%"},
+		{
+			name:    "Ascii",
+			profile: colorprofile.Ascii,
+			give:    400 * time.Millisecond,
+			want:    "latency=400ms ##--- 40%",
+		},
+		{
+			name:    "NoTTY",
+			profile: colorprofile.NoTTY,
+			give:    400 * time.Millisecond,
+			want:    "latency=400ms ##--- 40%",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			style := silog.PlainStyle()
+			style.ValueBars = map[string]silog.ValueBar{
+				"latency": {Max: time.Second, Width: 5},
+				"elapsed": {Width: 5}, // no max
+			}
+
+			var buffer strings.Builder
+			handler := silog.NewHandler(&buffer, &silog.HandlerOptions{
+				Style:        style,
+				ReplaceAttr:  skipTime,
+				ColorProfile: tt.profile,
+			})
+			slog.New(handler).Info("msg",
+				"latency", tt.give,
+				"elapsed", time.Second,
+				"other", time.Second,
+			)
+
+			assert.Equal(t, "INF msg  "+tt.want+" elapsed=1s other=1s\n", buffer.String())
+		})
+	}
+}
+
+func TestHandler_showGoroutineID(t *testing.T) {
+	var buffer strings.Builder
+	log := slog.New(silog.NewHandler(&buffer, &silog.HandlerOptions{
+		Style:           silog.PlainStyle(),
+		ReplaceAttr:     skipTime,
+		ShowGoroutineID: true,
+	}))
+
+	log.Info("foo\nbar", "k", "v")
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		log.Info("baz")
+	}()
+	<-done
+
+	lines := strings.Split(strings.TrimSuffix(buffer.String(), "\n"), "\n")
+	require.Len(t, lines, 3)
+	assert.Regexp(t, `^INF go\d+ foo$`, lines[0])
+	assert.Regexp(t, `^INF go\d+ bar  k=v$`, lines[1])
+	assert.Regexp(t, `^INF go\d+ baz$`, lines[2])
+
+	goroutineOf := func(line string) string {
+		return strings.Fields(line)[1]
+	}
+	assert.Equal(t, goroutineOf(lines[0]), goroutineOf(lines[1]),
+		"lines of a record should have the same goroutine ID")
+	assert.NotEqual(t, goroutineOf(lines[0]), goroutineOf(lines[2]),
+		"records from different goroutines should have different IDs")
+}
+
+func TestHandler_now(t *testing.T) {
+	clock := time.Date(2025, 1, 2, 15, 4, 5, 0, time.UTC)
+	now := func() time.Time { return clock }
+	recordTime := time.Date(2025, 1, 2, 9, 45, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		force bool
+		time  time.Time
+		want  string
+	}{
+		{name: "ZeroTime", want: "3:04PM INF foo\n"},
+		{name: "RecordTime", time: recordTime, want: "9:45AM INF foo\n"},
+		{name: "ForceZeroTime", force: true, want: "3:04PM INF foo\n"},
+		{name: "ForceRecordTime", force: true, time: recordTime, want: "3:04PM INF foo\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buffer strings.Builder
+			handler := silog.NewHandler(&buffer, &silog.HandlerOptions{
+				Style:      silog.PlainStyle(),
+				Now:        now,
+				ForceClock: tt.force,
+			})
+
+			rec := slog.NewRecord(tt.time, slog.LevelInfo, "foo", 0)
+			require.NoError(t, handler.Handle(t.Context(), rec))
+			assert.Equal(t, tt.want, buffer.String())
+		})
+	}
+
+	t.Run("Logger", func(t *testing.T) {
+		var buffer strings.Builder
+		handler := silog.NewHandler(&buffer, &silog.HandlerOptions{
+			Style:      silog.PlainStyle(),
+			Now:        now,
+			ForceClock: true,
+		})
+		slog.New(handler).Info("foo")
+		assert.Equal(t, "3:04PM INF foo\n", buffer.String())
+	})
+}
+
+func TestHandler_banner(t *testing.T) {
+	t.Run("Plain", func(t *testing.T) {
+		var writes []string
+		w := writerFunc(func(p []byte) (int, error) {
+			writes = append(writes, string(p))
+			return len(p), nil
+		})
+		handler := silog.NewHandler(w, &silog.HandlerOptions{
+			Style:       silog.PlainStyle(),
+			ReplaceAttr: skipTime,
+			Level:       slog.LevelError, // doesn't affect the banner
+		})
+
+		require.NoError(t, handler.Banner("myapp v1.2.3", "line 1\nline 2\n"))
+		require.NoError(t, handler.Banner()) // no-op
+		slog.New(handler).Error("foo")
+
+		assert.Equal(t, []string{
+			"myapp v1.2.3\nline 1\nline 2\n",
+			"ERR foo\n",
+		}, writes)
+	})
+
+	t.Run("Styled", func(t *testing.T) {
+		style := silog.PlainStyle()
+		style.Banner = lipgloss.NewStyle().Bold(true)
+
+		var buffer strings.Builder
+		handler := silog.NewHandler(&buffer, &silog.HandlerOptions{Style: style})
+		require.NoError(t, handler.Banner("foo\nbar"))
+		assert.Equal(t, "\x1b[1mfoo\x1b[m\n\x1b[1mbar\x1b[m\n", buffer.String())
+	})
+}
+
+func TestHandler_maxAttrs(t *testing.T) {
+	tests := []struct {
+		name string
+		opts silog.HandlerOptions
+		want string
+	}{
+		{
+			name: "Limited",
+			want: "INF foo  a=1 b=2 …(+3 more)\n",
+		},
+		{
+			name: "Priority",
+			opts: silog.HandlerOptions{AttrPriority: []string{"g.y", "d"}},
+			want: "INF foo  g.y=y d=4 …(+3 more)\n",
+		},
+		{
+			name: "Leading",
+			opts: silog.HandlerOptions{LeadingAttrs: []string{"d"}},
+			want: "INF 4 foo  a=1 …(+3 more)\n",
+		},
+		{
+			name: "AllLeading",
+			opts: silog.HandlerOptions{LeadingAttrs: []string{"a", "b", "d"}},
+			want: "INF 1 2 4 foo  …(+2 more)\n",
+		},
+		{
+			name: "MultilineCountsOnce",
+			opts: silog.HandlerOptions{AttrPriority: []string{"c"}},
+			want: "INF foo  \n" +
+				"  c=\n" +
+				"    | x\n" +
+				"    | y\n" +
+				"  a=1 …(+3 more)\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := tt.opts
+			opts.Style = silog.PlainStyle()
+			opts.ReplaceAttr = skipTime
+			opts.MaxAttrs = 2
+
+			var buffer strings.Builder
+			log := slog.New(silog.NewHandler(&buffer, &opts))
+			log.Info("foo", "a", 1, "b", 2, "c", "x\ny", "d", 4, slog.Group("g", "y", "y"))
+			assert.Equal(t, tt.want, buffer.String())
+		})
+	}
+
+	t.Run("NotExceeded", func(t *testing.T) {
+		var buffer strings.Builder
+		log := slog.New(silog.NewHandler(&buffer, &silog.HandlerOptions{
+			Style:       silog.PlainStyle(),
+			ReplaceAttr: skipTime,
+			MaxAttrs:    2,
+		}))
+		log.Info("foo", "a", 1, "b", 2)
+		assert.Equal(t, "INF foo  a=1 b=2\n", buffer.String())
+	})
+}
+
+func TestHandler_clampLevelOffset(t *testing.T) {
+	customStyle := silog.PlainStyle()
+	customStyle.LevelLabels = map[slog.Level]lipgloss.Style{
+		slog.LevelInfo:  lipgloss.NewStyle().SetString("INFO"),
+		slog.LevelError: lipgloss.NewStyle().SetString("FAIL"),
+	}
+
+	tests := []struct {
+		name   string
+		style  *silog.Style
+		offset int
+		level  slog.Level
+		want   string
+	}{
+		{"Down", silog.PlainStyle(), -12, slog.LevelError, "DBG foo\n"},
+		{"DownWithinRange", silog.PlainStyle(), -4, slog.LevelError, "WRN foo\n"},
+		{"Up", silog.PlainStyle(), 12, slog.LevelInfo, "ERR foo\n"},
+		{"AlreadyBelow", silog.PlainStyle(), -4, slog.LevelDebug - 4, "foo\n"},
+		{"AlreadyAbove", silog.PlainStyle(), 4, slog.LevelError + 4, "foo\n"},
+		{"CustomDown", customStyle, -12, slog.LevelError, "INFO foo\n"},
+		{"CustomUp", customStyle, 12, slog.LevelInfo, "FAIL foo\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buffer strings.Builder
+			handler := silog.NewHandler(&buffer, &silog.HandlerOptions{
+				Level:            slog.LevelDebug - 8,
+				Style:            tt.style,
+				ReplaceAttr:      skipTime,
+				ClampLevelOffset: true,
+			})
+
+			slog.New(handler.WithLevelOffset(tt.offset)).Log(t.Context(), tt.level, "foo")
+			assert.Equal(t, tt.want, buffer.String())
+		})
+	}
+}
+
+func TestHandler_multilineMarker(t *testing.T) {
+	tests := []struct {
+		name string
+		opts silog.HandlerOptions
+		log  func(*slog.Logger)
+		want string
+	}{
+		{
+			name: "SingleLine",
+			log:  func(l *slog.Logger) { l.Info("foo", "k", "v") },
+			want: "INF foo  k=v\n",
+		},
+		{
+			name: "Multiline",
+			log:  func(l *slog.Logger) { l.Info("foo", "k", "v", "err", "a\nb") },
+			want: "INF foo ⤷  k=v\n" +
+				"  err=\n" +
+				"    | a\n" +
+				"    | b\n",
+		},
+		{
+			name: "WithAttrs",
+			log:  func(l *slog.Logger) { l.With("err", "a\nb").Info("foo") },
+			want: "INF foo ⤷  err=\n" +
+				"    | a\n" +
+				"    | b\n",
+		},
+		{
+			name: "Deferred",
+			opts: silog.HandlerOptions{AttrPriority: []string{"err"}},
+			log:  func(l *slog.Logger) { l.Info("foo", "k", "v", "err", "a\nb") },
+			want: "INF foo ⤷  \n" +
+				"  err=\n" +
+				"    | a\n" +
+				"    | b\n" +
+				"  k=v\n",
+		},
+		{
+			name: "MultilineMessage",
+			log:  func(l *slog.Logger) { l.Info("foo\nbar", "err", "a\nb") },
+			want: "INF foo\n" +
+				"INF bar ⤷  \n" +
+				"  err=\n" +
+				"    | a\n" +
+				"    | b\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := tt.opts
+			opts.Style = silog.PlainStyle()
+			opts.ReplaceAttr = skipTime
+			opts.MultilineMarker = true
+
+			var buffer strings.Builder
+			tt.log(slog.New(silog.NewHandler(&buffer, &opts)))
+			assert.Equal(t, tt.want, buffer.String())
+		})
+	}
+}
+
+func TestHandler_showTimeDelta(t *testing.T) {
+	var buffer strings.Builder
+	handler := silog.NewHandler(&buffer, &silog.HandlerOptions{
+		Style:         silog.PlainStyle(),
+		TimeFormat:    "15:04:05.000",
+		ShowTimeDelta: true,
+	})
+	derived := handler.WithPrefix("sub")
+
+	start := time.Date(2025, 1, 2, 9, 45, 1, 0, time.UTC)
+	ctx := t.Context()
+	require.NoError(t, handler.Handle(ctx, slog.NewRecord(start, slog.LevelInfo, "foo", 0)))
+	require.NoError(t, derived.Handle(ctx, slog.NewRecord(start.Add(12*time.Millisecond), slog.LevelInfo, "bar", 0)))
+	require.NoError(t, handler.Handle(ctx, slog.NewRecord(time.Time{}, slog.LevelInfo, "no time", 0)))
+	require.NoError(t, handler.Handle(ctx, slog.NewRecord(start.Add(2*time.Second), slog.LevelWarn, "baz", 0)))
+	require.NoError(t, handler.Handle(ctx, slog.NewRecord(start.Add(time.Second), slog.LevelInfo, "qux", 0)))
+
+	assert.Equal(t,
+		"09:45:01.000 INF foo\n"+
+			"09:45:01.012 (+12ms) INF sub: bar\n"+
+			"INF no time\n"+
+			"09:45:03.000 (+1.99s) WRN baz\n"+
+			"09:45:02.000 (-1s) INF qux\n",
+		buffer.String())
+}
+
+func TestHandler_accessors(t *testing.T) {
+	var lvl slog.LevelVar
+	style := silog.PlainStyle()
+	handler := silog.NewHandler(io.Discard, &silog.HandlerOptions{
+		Level:      &lvl,
+		Style:      style,
+		TimeFormat: time.RFC3339,
+	})
+
+	assert.Equal(t, slog.LevelInfo, handler.Level())
+	lvl.Set(slog.LevelDebug)
+	assert.Equal(t, slog.LevelDebug, handler.Level())
+	assert.Equal(t, slog.LevelWarn, handler.WithLevel(slog.LevelWarn).Level())
+
+	assert.Equal(t, time.RFC3339, handler.TimeFormat())
+	assert.Equal(t, time.Kitchen, silog.NewHandler(io.Discard, nil).TimeFormat())
+
+	assert.Equal(t, colorprofile.Unknown, handler.ColorProfile())
+	assert.Equal(t, colorprofile.ANSI256, silog.NewHandler(io.Discard, &silog.HandlerOptions{
+		ColorProfile: colorprofile.ANSI256,
+	}).WithPrefix("p").ColorProfile())
+
+	t.Run("Style", func(t *testing.T) {
+		got := handler.Style()
+		assert.Equal(t, style, got)
+		assert.NotSame(t, style, got)
+
+		// Modifying the returned style does not affect the handler.
+		got.Values["k"] = lipgloss.NewStyle().Bold(true)
+		got.LevelLabels[slog.LevelInfo] = lipgloss.NewStyle().SetString("INFO")
+		assert.NotContains(t, handler.Style().Values, "k")
+		assert.Equal(t, "INF", handler.Style().LevelLabels[slog.LevelInfo].Value())
+	})
+}
+
+func TestHandler_nullValueStyle(t *testing.T) {
+	style := silog.PlainStyle()
+	style.NullValue = lipgloss.NewStyle().SetString("<nil>")
+
+	var buffer strings.Builder
+	log := slog.New(silog.NewHandler(&buffer, &silog.HandlerOptions{
+		Style:       style,
+		ReplaceAttr: skipTime,
+	}))
+
+	log.Info("foo", "k1", nil, "k2", (*int)(nil))
+	assert.Equal(t, "INF foo  k1=<nil> k2=<nil>\n", buffer.String())
+}
+
+func TestHandler_colorDelimiterWithValue(t *testing.T) {
+	style := silog.PlainStyle()
+	style.Values["error"] = lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+
+	var buffer strings.Builder
+	log := slog.New(silog.NewHandler(&buffer, &silog.HandlerOptions{
+		Style:                   style,
+		ReplaceAttr:             skipTime,
+		ColorDelimiterWithValue: true,
+	}))
+
+	log.Info("foo", "k", "v", "error", "bar")
+	log.Info("foo", "error", "bar\nbaz")
+
+	assert.Equal(t,
+		"INF foo  k=v error\x1b[31m=\x1b[m\x1b[31mbar\x1b[m\n"+
+			"INF foo  \n"+
+			"  error\x1b[31m=\x1b[m\n"+
+			"  \x1b[31m  | \x1b[m\x1b[31mbar\x1b[m\n"+
+			"  \x1b[31m  | \x1b[m\x1b[31mbaz\x1b[m\n",
+		buffer.String())
+}
+
+func TestHandler_styledMultilineValue(t *testing.T) {
+	style := silog.PlainStyle()
+	style.Key = lipgloss.NewStyle().Faint(true)
+	style.Values["error"] = lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+
+	tests := []struct {
+		name string
+		opts silog.HandlerOptions
+		want string
+	}{
+		{
+			name: "Default",
+			want: "INF foo  \x1b[2merror\x1b[m=\x1b[31mbar\x1b[m\n" +
+				"INF foo  \n" +
+				"  \x1b[2merror\x1b[m=\n" +
+				"  \x1b[31m  | \x1b[m\x1b[31mbar\x1b[m\n" +
+				"  \x1b[31m  | \x1b[m\x1b[31mbaz\x1b[m\n",
+		},
+		{
+			name: "ColorKeyAndDelimiter",
+			opts: silog.HandlerOptions{
+				ColorKeyWithValue:       true,
+				ColorDelimiterWithValue: true,
+			},
+			want: "INF foo  \x1b[2;31merror\x1b[m\x1b[31m=\x1b[m\x1b[31mbar\x1b[m\n" +
+				"INF foo  \n" +
+				"  \x1b[2;31merror\x1b[m\x1b[31m=\x1b[m\n" +
+				"  \x1b[31m  | \x1b[m\x1b[31mbar\x1b[m\n" +
+				"  \x1b[31m  | \x1b[m\x1b[31mbaz\x1b[m\n",
+		},
+		{
+			name: "NeutralPrefix",
+			opts: silog.HandlerOptions{
+				ColorKeyWithValue:      true,
+				NeutralMultilinePrefix: true,
+			},
+			want: "INF foo  \x1b[2;31merror\x1b[m=\x1b[31mbar\x1b[m\n" +
+				"INF foo  \n" +
+				"  \x1b[2;31merror\x1b[m=\n" +
+				"    | \x1b[31mbar\x1b[m\n" +
+				"    | \x1b[31mbaz\x1b[m\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := tt.opts
+			opts.Style = style
+			opts.ReplaceAttr = skipTime
+
+			var buffer strings.Builder
+			log := slog.New(silog.NewHandler(&buffer, &opts))
+			log.Info("foo", "error", "bar")
+			log.Info("foo", "error", "bar\nbaz")
+
+			assert.Equal(t, tt.want, buffer.String())
+		})
+	}
+}
+
+func TestHandler_valuesByLevel(t *testing.T) {
+	red := lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+	muted := lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+	bold := lipgloss.NewStyle().Bold(true)
+
+	style := silog.PlainStyle()
+	style.Values["error"] = red
+	style.ValuesByLevel = map[slog.Level]map[string]lipgloss.Style{
+		slog.LevelWarn: {"error": muted},
+		slog.LevelInfo: {"status": bold},
+	}
+
+	var buffer strings.Builder
+	handler := silog.NewHandler(&buffer, &silog.HandlerOptions{
+		Style:        style,
+		ReplaceAttr:  skipTime,
+		LeadingAttrs: []string{"status"},
+	})
+	log := slog.New(handler).With("error", "x")
+
+	log.Error("foo")                 // Values
+	log.Warn("bar")                  // ValuesByLevel
+	log.Info("baz", "status", "ok")  // Values, and ValuesByLevel for another key
+	log.Debug("qux", "status", "ok") // not logged
+	slog.New(handler.WithLevelOffset(-4)).Error("quux", "error", "y")
+
+	assert.Equal(t,
+		"ERR foo  error="+red.Render("x")+"\n"+
+			"WRN bar  error="+muted.Render("x")+"\n"+
+			"INF "+bold.Render("ok")+" baz  error="+red.Render("x")+"\n"+
+			"WRN quux  error="+muted.Render("y")+"\n",
+		buffer.String())
+}
+
+func TestHandler_groupAttrsTogether(t *testing.T) {
+	var buffer strings.Builder
+	handler := silog.NewHandler(&buffer, &silog.HandlerOptions{
+		Style:              silog.PlainStyle(),
+		ReplaceAttr:        skipTime,
+		GroupAttrsTogether: true,
+		DefaultAttrs:       []slog.Attr{slog.String("svc", "api")},
+	})
+	log := slog.New(handler)
+
+	assertLines := func(t *testing.T, lines ...string) {
+		t.Helper()
+
+		assert.Equal(t, strings.Join(lines, "\n")+"\n", buffer.String())
+		buffer.Reset()
+	}
+
+	t.Run("Interleaved", func(t *testing.T) {
+		log.Info("foo",
+			slog.Group("a", "x", 1),
+			slog.Group("b", "y", 2),
+			"z", 3,
+			slog.Group("a", "w", 4),
+		)
+		assertLines(t, "INF foo  svc=api a.x=1 a.w=4 b.y=2 z=3")
+	})
+
+	t.Run("Nested", func(t *testing.T) {
+		log.Info("foo",
+			slog.Group("a", slog.Group("b", "x", 1)),
+			slog.Group("a", "y", 2),
+			slog.Group("a", slog.Group("b", "z", 3)),
+			slog.Group("", "inline", 4),
+		)
+		assertLines(t, "INF foo  svc=api a.b.x=1 a.b.z=3 a.y=2 inline=4")
+	})
+
+	t.Run("WithAttrsAndGroups", func(t *testing.T) {
+		log := log.
+			With(slog.Group("req", "id", 1)).
+			WithGroup("db").With("table", "users").
+			WithGroup("")
+		log.Info("foo",
+			"rows", 3,
+			slog.Group("req", "retry", true),
+		)
+		assertLines(t, "INF foo  svc=api req.id=1 db.table=users db.rows=3 db.req.retry=true")
+	})
+
+	t.Run("Multiline", func(t *testing.T) {
+		log.With(slog.Group("a", "x", "1\n2")).Info("foo", "b", 2, slog.Group("a", "y", 3))
+		assertLines(t,
+			"INF foo  svc=api",
+			"  a.x=",
+			"    | 1",
+			"    | 2",
+			"  a.y=3 b=2",
+		)
+	})
+}
+
+func TestHandler_detailWriter(t *testing.T) {
+	var primary, detail strings.Builder
+	handler := silog.NewHandler(&primary, &silog.HandlerOptions{
+		Style:        silog.PlainStyle(),
+		ReplaceAttr:  skipTime,
+		DetailWriter: &detail,
+		SummaryKeys:  []string{"status", "req.id"},
+	})
+	log := slog.New(handler).With(slog.Group("req", "id", 42, "path", "/"))
+
+	log.Info("request done", "status", 200, "body", "foo\nbar")
+	log.WithGroup("req").Warn("slow", "ms", 1200)
+
+	assert.Equal(t,
+		"INF request done  req.id=42 status=200 ref=1\n"+
+			"WRN slow  req.id=42 ref=2\n",
+		primary.String())
+	assert.Equal(t,
+		"INF request done  req.id=42 req.path=/ status=200\n"+
+			"  body=\n"+
+			"    | foo\n"+
+			"    | bar\n"+
+			"  ref=1\n"+
+			"WRN slow  req.id=42 req.path=/ req.ms=1200 ref=2\n",
+		detail.String())
+}
+
+func TestHandler_attrPriority(t *testing.T) {
+	tests := []struct {
+		name     string
+		priority []string
+		together bool
+		want     string
+	}{
+		{
+			name:     "Default",
+			priority: []string{"status", "req.id", "missing"},
+			want:     "INF msg  status=500 req.id=1 a=1 b=2 req.path=/ c=3\n",
+		},
+		{
+			name:     "GroupAttrsTogether",
+			priority: []string{"status"},
+			together: true,
+			want:     "INF msg  status=500 a=1 b=2 req.path=/ req.id=1 c=3\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buffer strings.Builder
+			handler := silog.NewHandler(&buffer, &silog.HandlerOptions{
+				Style:              silog.PlainStyle(),
+				ReplaceAttr:        skipTime,
+				AttrPriority:       tt.priority,
+				GroupAttrsTogether: tt.together,
+			})
+
+			slog.New(handler).With("a", 1).Info("msg",
+				"b", 2,
+				slog.Group("req", "path", "/"),
+				"c", 3,
+				slog.Group("req", "id", 1),
+				"status", 500,
+			)
+			assert.Equal(t, tt.want, buffer.String())
+		})
+	}
+}
+
+func TestHandler_leadingAttrs(t *testing.T) {
+	style := silog.PlainStyle()
+	style.Values["status"] = lipgloss.NewStyle().Bold(true)
+
+	var buffer strings.Builder
+	handler := silog.NewHandler(&buffer, &silog.HandlerOptions{
+		Style:        style,
+		ReplaceAttr:  skipTime,
+		LeadingAttrs: []string{"status", "req.method"},
+	})
+	log := slog.New(handler)
+
+	log.With(slog.Group("req", "method", "GET")).
+		Info("/users", "duration", "3ms", "status", 200)
+	log.Info("no leading attrs", "foo", "bar")
+	log.Warn("multi-line\nmessage", "status", 404)
+	log.Error("multi-line value", "status", "5xx\nerror")
+
+	bold := func(s string) string {
+		return lipgloss.NewStyle().Bold(true).Render(s)
+	}
+	assert.Equal(t,
+		"INF "+bold("200")+" GET /users  duration=3ms\n"+
+			"INF no leading attrs  foo=bar\n"+
+			"WRN "+bold("404")+" multi-line\n"+
+			"WRN "+bold("404")+" message\n"+
+			"ERR multi-line value  \n"+
+			"  status=\n"+
+			"    | "+bold("5xx")+"\n"+
+			"    | "+bold("error")+"\n",
+		buffer.String())
+}
+
+func TestHandler_highlightMessagePairs(t *testing.T) {
+	key := lipgloss.NewStyle().Bold(true)
+	style := silog.PlainStyle()
+	style.Key = key
+	style.Messages[slog.LevelDebug] = lipgloss.NewStyle().Faint(true)
+
+	tests := []struct {
+		name string
+		msg  string
+		want string
+	}{
+		{
+			name: "Pairs",
+			msg:  "retrying op=fetch attempt=2",
+			want: "retrying " + key.Render("op") + "=fetch " + key.Render("attempt") + "=2",
+		},
+		{
+			name: "Prose",
+			msg:  "x = y, and 2=3 is (a=b) wrong",
+			want: "x = y, and 2=3 is (a=b) wrong",
+		},
+		{name: "EmptyValue", msg: "key= value", want: "key= value"},
+		{name: "EmptyKey", msg: "=value", want: "=value"},
+		{name: "MultipleEquals", msg: "a=b=c", want: "a=b=c"},
+		{
+			name: "Identifier",
+			msg:  "_req.id=1 http-status=200 .x=1 -y=2",
+			want: key.Render("_req.id") + "=1 " + key.Render("http-status") + "=200 .x=1 -y=2",
+		},
+		{
+			name: "Whitespace",
+			msg:  "  a=1\tb=2",
+			want: "  " + key.Render("a") + "=1\t" + key.Render("b") + "=2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buffer strings.Builder
+			handler := silog.NewHandler(&buffer, &silog.HandlerOptions{
+				Style:                 style,
+				ReplaceAttr:           skipTime,
+				HighlightMessagePairs: true,
+			})
+
+			slog.New(handler).Info(tt.msg)
+			assert.Equal(t, "INF "+tt.want+"\n", buffer.String())
+		})
+	}
+
+	t.Run("MultilineMessageStyle", func(t *testing.T) {
+		var buffer strings.Builder
+		handler := silog.NewHandler(&buffer, &silog.HandlerOptions{
+			Level:                 slog.LevelDebug,
+			Style:                 style,
+			ReplaceAttr:           skipTime,
+			HighlightMessagePairs: true,
+		})
+
+		slog.New(handler).Debug("a=1\nnone\nb=2")
+		assert.Equal(t,
+			"DBG \x1b[2m\x1b[1ma\x1b[m\x1b[2m=1\x1b[m\n"+
+				"DBG \x1b[2mnone\x1b[m\n"+
+				"DBG \x1b[2m\x1b[1mb\x1b[m\x1b[2m=2\x1b[m\n",
+			buffer.String())
+	})
+
+	t.Run("NoColor", func(t *testing.T) {
+		for _, profile := range []colorprofile.Profile{colorprofile.NoTTY, colorprofile.Ascii} {
+			var buffer strings.Builder
+			handler := silog.NewHandler(&buffer, &silog.HandlerOptions{
+				Style:                 style,
+				ReplaceAttr:           skipTime,
+				HighlightMessagePairs: true,
+				ColorProfile:          profile,
+			})
+
+			slog.New(handler).Info("op=fetch")
+			assert.Equal(t, "INF op=fetch\n", buffer.String(), "profile: %v", profile)
+		}
+	})
+}
+
+func TestHandler_trailingSpace(t *testing.T) {
+	tests := []struct {
+		name string
+		give func(*slog.Logger)
+
+		trimmed   string
+		preserved string
+	}{
+		{
+			name:      "Message",
+			give:      func(log *slog.Logger) { log.Info("foo ") },
+			trimmed:   "INF foo\n",
+			preserved: "INF foo \n",
+		},
+		{
+			name:      "MessageNewline",
+			give:      func(log *slog.Logger) { log.Info("foo \n") },
+			trimmed:   "INF foo\n",
+			preserved: "INF foo \n",
+		},
+		{
+			name:      "MultilineMessage",
+			give:      func(log *slog.Logger) { log.Info("foo \nbar ") },
+			trimmed:   "INF foo \nINF bar\n",
+			preserved: "INF foo \nINF bar \n",
+		},
+		{
+			name:      "MessageWithAttrs",
+			give:      func(log *slog.Logger) { log.Info("foo ", "k", "v") },
+			trimmed:   "INF foo   k=v\n",
+			preserved: "INF foo   k=v\n",
+		},
+		{
+			name:      "AttrValue",
+			give:      func(log *slog.Logger) { log.Info("foo", "k", "v ") },
+			trimmed:   "INF foo  k=v\n",
+			preserved: "INF foo  k=v \n",
+		},
+		{
+			name: "MultilineAttrValue",
+			give: func(log *slog.Logger) { log.Info("foo", "k", "a \nb ") },
+			trimmed: "INF foo  \n" +
+				"  k=\n" +
+				"    | a \n" +
+				"    | b\n",
+			preserved: "INF foo  \n" +
+				"  k=\n" +
+				"    | a \n" +
+				"    | b \n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, preserve := range []bool{false, true} {
+				var buffer strings.Builder
+				handler := silog.NewHandler(&buffer, &silog.HandlerOptions{
+					Style:                 silog.PlainStyle(),
+					ReplaceAttr:           skipTime,
+					PreserveTrailingSpace: preserve,
+				})
+				tt.give(slog.New(handler))
+
+				want := tt.trimmed
+				if preserve {
+					want = tt.preserved
+				}
+				assert.Equal(t, want, buffer.String(), "preserve=%v", preserve)
+			}
+		})
+	}
+
+	t.Run("AttrsOnNewLine", func(t *testing.T) {
+		var buffer strings.Builder
+		handler := silog.NewHandler(&buffer, &silog.HandlerOptions{
+			Style:                 silog.PlainStyle(),
+			ReplaceAttr:           skipTime,
+			AttrsOnNewLine:        true,
+			PreserveTrailingSpace: true,
+		})
+		log := slog.New(handler)
+		log.Info("foo ")
+		log.Info("bar ", "k", "v ")
+
+		assert.Equal(t, "INF foo \nINF bar \n  k=v \n", buffer.String())
+	})
+}
+
+func TestHandler_renderLevel(t *testing.T) {
+	var buffer strings.Builder
+	handler := silog.NewHandler(&buffer, &silog.HandlerOptions{
+		Level:       slog.LevelDebug,
+		Style:       silog.PlainStyle(),
+		ReplaceAttr: skipTime,
+		RenderLevel: func(lvl slog.Level, style *silog.Style) string {
+			if lvl < slog.LevelInfo {
+				return "" // no label
+			}
+			return "[" + style.LevelLabels[lvl].String() + "]"
+		},
+	})
+
+	log := slog.New(handler)
+	log.Info("foo")
+	log.Debug("bar")
+	slog.New(handler.WithLevelOffset(-4)).Error("baz")
+
+	assert.Equal(t, "[INF] foo\nbar\n[WRN] baz\n", buffer.String())
+
+	t.Run("ReplaceAttr", func(t *testing.T) {
+		buffer.Reset()
+		handler := silog.NewHandler(&buffer, &silog.HandlerOptions{
+			Style: silog.PlainStyle(),
+			ReplaceAttr: func(groups []string, attr slog.Attr) slog.Attr {
+				if len(groups) > 0 {
+					return attr
+				}
+				switch attr.Key {
+				case slog.TimeKey:
+					return slog.Attr{}
+				case slog.LevelKey:
+					switch lvl := attr.Value.Any().(slog.Level); lvl {
+					case slog.LevelWarn:
+						return slog.Any(slog.LevelKey, slog.LevelError)
+					case slog.LevelError:
+						return slog.String(slog.LevelKey, "FATAL")
+					case slog.LevelInfo + 1:
+						return slog.Attr{}
+					}
+				}
+				return attr
+			},
+			RenderLevel: func(lvl slog.Level, _ *silog.Style) string {
+				return "<" + lvl.String() + ">"
+			},
+		})
+
+		log := slog.New(handler)
+		log.Info("foo")
+		log.Warn("bar")
+		log.Error("baz")
+		log.Log(t.Context(), slog.LevelInfo+1, "qux")
+
+		assert.Equal(t,
+			"<INFO> foo\n<ERROR> bar\nFATAL baz\nqux\n",
+			buffer.String())
+	})
+}
+
+func TestHandler_anyFormat(t *testing.T) {
+	type point struct {
+		X, Y int
+	}
+	type badJSON struct {
+		C chan int
+	}
+
+	tests := []struct {
+		name   string
+		give   any
+		goWant string
+		json   string
+	}{
+		{
+			name:   "Struct",
+			give:   point{1, 2},
+			goWant: "{1 2}",
+			json:   `{"X":1,"Y":2}`,
+		},
+		{
+			name:   "Pointer",
+			give:   &point{1, 2},
+			goWant: "&{1 2}",
+			json:   `{"X":1,"Y":2}`,
+		},
+		{
+			name:   "Map",
+			give:   map[string]int{"b": 2, "a": 1},
+			goWant: "map[a:1 b:2]",
+			json:   `{"a":1,"b":2}`,
+		},
+		{
+			name:   "Slice",
+			give:   []string{"a", "b"},
+			goWant: "[a b]",
+			json:   `["a","b"]`,
+		},
+		{
+			name:   "Array",
+			give:   [2]int{1, 2},
+			goWant: "[1 2]",
+			json:   "[1,2]",
+		},
+		{
+			name:   "Stringer",
+			give:   testStringTextMarshaler{"foo"},
+			goWant: "string:foo",
+			json:   "string:foo",
+		},
+		{
+			name:   "MarshalError",
+			give:   badJSON{},
+			goWant: "{<nil>}",
+			json:   "{<nil>}",
+		},
+		{
+			name:   "NotComposite",
+			give:   complex(1, 2),
+			goWant: "(1+2i)",
+			json:   "(1+2i)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for format, want := range map[silog.AnyFormat]string{
+				silog.AnyFormatGoInline: tt.goWant,
+				silog.AnyFormatJSON:     tt.json,
+			} {
+				var buffer strings.Builder
+				handler := silog.NewHandler(&buffer, &silog.HandlerOptions{
+					Style:       silog.PlainStyle(),
+					ReplaceAttr: skipTime,
+					AnyFormat:   format,
+				})
+
+				slog.New(handler).Info("msg", "v", tt.give)
+				assert.Equal(t, "INF msg  v="+want+"\n", buffer.String(), "format=%v", format)
+			}
+		})
+	}
+
+	t.Run("Long", func(t *testing.T) {
+		var buffer strings.Builder
+		handler := silog.NewHandler(&buffer, &silog.HandlerOptions{
+			Style:       silog.PlainStyle(),
+			ReplaceAttr: skipTime,
+			AnyFormat:   silog.AnyFormatJSON,
+		})
+
+		slog.New(handler).Info("msg", "v", map[string]string{
+			"name":        "example",
+			"description": strings.Repeat("x", 60),
+		})
+		assert.Equal(t,
+			"INF msg  \n"+
+				"  v=\n"+
+				"    | {\n"+
+				`    |   "description": "`+strings.Repeat("x", 60)+`",`+"\n"+
+				`    |   "name": "example"`+"\n"+
+				"    | }\n",
+			buffer.String())
+	})
+}
+
+func TestHandler_levelWriters(t *testing.T) {
+	var stdout, stderr, detail strings.Builder
+	handler := silog.NewHandler(&stdout, &silog.HandlerOptions{
+		Level:       slog.LevelDebug,
+		Style:       silog.PlainStyle(),
+		ReplaceAttr: skipTime,
+		LevelWriters: map[slog.Level]io.Writer{
+			slog.LevelWarn:  &stderr,
+			slog.LevelError: nil, // ignored
+		},
+	})
+
+	log := slog.New(handler)
+	log.Debug("foo")
+	log.Info("bar")
+	log.Warn("baz")
+	log.Error("qux")
+	slog.New(handler.WithLevelOffset(-4)).Error("downgraded")
+
+	assert.Equal(t, "DBG foo\nINF bar\n", stdout.String())
+	assert.Equal(t, "WRN baz\nERR qux\nWRN downgraded\n", stderr.String())
+
+	t.Run("DetailWriter", func(t *testing.T) {
+		stdout.Reset()
+		stderr.Reset()
+
+		handler := silog.NewHandler(&stdout, &silog.HandlerOptions{
+			Style:        silog.PlainStyle(),
+			ReplaceAttr:  skipTime,
+			DetailWriter: &detail,
+			LevelWriters: map[slog.Level]io.Writer{
+				slog.LevelError: &stderr,
+			},
+		})
+
+		log := slog.New(handler)
+		log.Info("foo", "k", "v")
+		log.Error("bar", "k", "v")
+
+		assert.Equal(t, "INF foo  ref=1\n", stdout.String())
+		assert.Equal(t, "ERR bar  ref=2\n", stderr.String())
+		assert.Equal(t, "INF foo  k=v ref=1\nERR bar  k=v ref=2\n", detail.String())
+	})
+}
+
+func TestNewStdioHandler(t *testing.T) {
+	dir := t.TempDir()
+	stdout, err := os.Create(filepath.Join(dir, "stdout"))
+	require.NoError(t, err)
+	stderr, err := os.Create(filepath.Join(dir, "stderr"))
+	require.NoError(t, err)
+
+	oldStdout, oldStderr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = stdout, stderr
+	handler := silog.NewStdioHandler(&silog.HandlerOptions{
+		Style:       silog.PlainStyle(),
+		ReplaceAttr: skipTime,
+	})
+	os.Stdout, os.Stderr = oldStdout, oldStderr
+
+	log := slog.New(handler)
+	log.Info("foo")
+	log.Warn("bar")
+	log.Error("baz")
+	require.NoError(t, stdout.Close())
+	require.NoError(t, stderr.Close())
+
+	got, err := os.ReadFile(stdout.Name())
+	require.NoError(t, err)
+	assert.Equal(t, "INF foo\n", string(got))
+
+	got, err = os.ReadFile(stderr.Name())
+	require.NoError(t, err)
+	assert.Equal(t, "WRN bar\nERR baz\n", string(got))
+}
+
+func TestHandler_sanitizeUTF8(t *testing.T) {
+	const bad = "a\xffb\xc3"
+
+	for _, sanitize := range []bool{false, true} {
+		var buffer strings.Builder
+		handler := silog.NewHandler(&buffer, &silog.HandlerOptions{
+			Style:        silog.PlainStyle(),
+			ReplaceAttr:  skipTime,
+			SanitizeUTF8: sanitize,
+		})
+
+		slog.New(handler).Info("msg "+bad+"\nok", "k", bad, "err", errors.New(bad))
+		if sanitize {
+			const good = "a�b�"
+			assert.Equal(t,
+				"INF msg "+good+"\nINF ok  k="+good+" err="+good+"\n",
+				buffer.String())
+		} else {
+			assert.Equal(t,
+				"INF msg "+bad+"\nINF ok  k="+bad+" err="+bad+"\n",
+				buffer.String())
+		}
+	}
+}
+
+func TestHandler_stripIncomingANSI(t *testing.T) {
+	const (
+		msg   = "build \x1b[32mok\x1b[0m\n\x1b[1mdone\x1b[m"
+		value = "\x1b[31mfailed\x1b[0m"
+	)
+
+	tests := []struct {
+		name    string
+		strip   bool
+		profile colorprofile.Profile
+		want    string
+	}{
+		{
+			name:    "Ascii",
+			strip:   true,
+			profile: colorprofile.Ascii,
+			want: "INF build ok\n" +
+				"INF done  k=failed err=failed any=[failed] nil=\x1b[2m<nil>\x1b[m\n",
+		},
+		{
+			name:    "NoTTY",
+			strip:   true,
+			profile: colorprofile.NoTTY,
+			want: "INF build ok\n" +
+				"INF done  k=failed err=failed any=[failed] nil=\x1b[2m<nil>\x1b[m\n",
+		},
+		{
+			name:    "Color",
+			strip:   true,
+			profile: colorprofile.ANSI,
+			want: "INF build \x1b[32mok\x1b[0m\n" +
+				"INF \x1b[1mdone\x1b[m  k=" + value + " err=" + value +
+				" any=[" + value + "] nil=\x1b[2m<nil>\x1b[m\n",
+		},
+		{
+			name:    "Disabled",
+			profile: colorprofile.Ascii,
+			want: "INF build \x1b[32mok\x1b[0m\n" +
+				"INF \x1b[1mdone\x1b[m  k=" + value + " err=" + value +
+				" any=[" + value + "] nil=\x1b[2m<nil>\x1b[m\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			style := silog.PlainStyle()
+			// Escape sequences from the style are not removed.
+			style.NullValue = lipgloss.NewStyle().SetString("<nil>").Faint(true)
+
+			var buffer strings.Builder
+			handler := silog.NewHandler(&buffer, &silog.HandlerOptions{
+				Style:             style,
+				ReplaceAttr:       skipTime,
+				ColorProfile:      tt.profile,
+				StripIncomingANSI: tt.strip,
+			})
+
+			slog.New(handler).Info(msg,
+				"k", value,
+				"err", errors.New(value),
+				"any", []string{value},
+				"nil", nil,
+			)
+			assert.Equal(t, tt.want, buffer.String())
+		})
+	}
+}
+
+func TestHandler_flattenKeys(t *testing.T) {
+	style := silog.PlainStyle()
+	style.Values["id"] = lipgloss.NewStyle().Bold(true)
+
+	var groupsSeen [][]string
+	var buffer strings.Builder
+	handler := silog.NewHandler(&buffer, &silog.HandlerOptions{
+		Style: style,
+		ReplaceAttr: func(groups []string, attr slog.Attr) slog.Attr {
+			if len(groups) == 0 && attr.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			if attr.Key == "id" {
+				groupsSeen = append(groupsSeen, groups)
+			}
+			return attr
+		},
+		FlattenKeys: true,
+	})
+
+	slog.New(handler).WithGroup("req").
+		With(slog.Group("user", "id", 1)).
+		Info("foo", "id", 2, "k", "v")
+
+	bold := lipgloss.NewStyle().Bold(true)
+	assert.Equal(t,
+		"INF foo  id="+bold.Render("1")+" id="+bold.Render("2")+" k=v\n",
+		buffer.String())
+	assert.Equal(t, [][]string{{"req", "user"}, {"req"}}, groupsSeen)
+}
+
+func TestHandler_respectContextCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	for _, respect := range []bool{false, true} {
+		var buffer strings.Builder
+		handler := silog.NewHandler(&buffer, &silog.HandlerOptions{
+			Style:                      silog.PlainStyle(),
+			ReplaceAttr:                skipTime,
+			RespectContextCancellation: respect,
+		})
+
+		log := slog.New(handler)
+		log.InfoContext(t.Context(), "foo")
+		log.InfoContext(ctx, "bar")
+
+		err := handler.Handle(ctx, slog.NewRecord(time.Time{}, slog.LevelInfo, "baz", 0))
+		if respect {
+			assert.ErrorIs(t, err, context.Canceled)
+			assert.Equal(t, "INF foo\n", buffer.String())
+		} else {
+			assert.NoError(t, err)
+			assert.Equal(t, "INF foo\nINF bar\nINF baz\n", buffer.String())
+		}
+	}
+}
+
+func TestHandler_messageWidth(t *testing.T) {
+	var buffer strings.Builder
+	handler := silog.NewHandler(&buffer, &silog.HandlerOptions{
+		Style:        silog.DefaultStyle(),
+		ReplaceAttr:  skipTime,
+		MessageWidth: 10,
+	})
+
+	log := slog.New(handler)
+	log.Info("foo", "k", "v")
+	log.Info("longer message", "k", "v")
+	log.Info("bar")
+	log.Info("first\nsecond", "k", "v")
+	slog.New(handler.WithPrefix("p")).WithGroup("g").Info("x", "k", "v")
+
+	assert.Equal(t, strings.Join([]string{
+		"INF foo         k=v",
+		"INF longer message  k=v",
+		"INF bar",
+		"INF first",
+		"INF second      k=v",
+		"INF p: x        g.k=v",
+	}, "\n")+"\n", ansi.Strip(buffer.String()))
+}
+
+func TestHandler_writeTimeout(t *testing.T) {
+	w := &blockingWriter{unblock: make(chan struct{})}
+	handler := silog.NewHandler(w, &silog.HandlerOptions{
+		Style:        silog.PlainStyle(),
+		ReplaceAttr:  skipTime,
+		WriteTimeout: 10 * time.Millisecond,
+	})
+
+	log := slog.New(handler)
+	log.Info("ok")
+	assert.Equal(t, "INF ok\n", w.String())
+
+	w.block.Store(true)
+	err := handler.Handle(t.Context(), slog.NewRecord(time.Time{}, slog.LevelInfo, "stuck", 0))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// Still stuck on the previous write.
+	err = handler.Handle(t.Context(), slog.NewRecord(time.Time{}, slog.LevelInfo, "dropped", 0))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	w.block.Store(false)
+	close(w.unblock)
+	assert.EventuallyWithT(t, func(c *assert.CollectT) {
+		err := handler.Handle(t.Context(), slog.NewRecord(time.Time{}, slog.LevelInfo, "recovered", 0))
+		assert.NoError(c, err)
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, "INF ok\nINF stuck\nINF recovered\n", w.String())
+}
+
+// blockingWriter is a writer that blocks while block is set
+// until unblock is closed.
+type blockingWriter struct {
+	block   atomic.Bool
+	unblock chan struct{}
+
+	mu  sync.Mutex
+	buf strings.Builder
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	if w.block.Load() {
+		<-w.unblock
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+func (w *blockingWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.String()
+}
+
+func TestHandler_groupBraces(t *testing.T) {
+	tests := []struct {
+		name     string
+		together bool
+		build    func(*slog.Logger) *slog.Logger
+		give     []any
+		want     string
+	}{
+		{
+			name: "Group",
+			give: []any{
+				"id", 1,
+				slog.Group("request",
+					slog.Group("headers", "method", "POST", "path", "/x"),
+				),
+				"k", "v",
+			},
+			want: "INF msg  id=1 request.headers{method=POST path=/x} k=v\n",
+		},
+		{
+			name: "Nested",
+			give: []any{
+				slog.Group("a", "x", 1, slog.Group("b", "y", 2), "z", 3),
+			},
+			want: "INF msg  a{x=1 b{y=2} z=3}\n",
+		},
+		{
+			name: "WithGroup",
+			build: func(log *slog.Logger) *slog.Logger {
+				return log.WithGroup("g").With("a", 1)
+			},
+			give: []any{"b", 2, slog.Group("h", "c", 3)},
+			want: "INF msg  g{a=1 b=2 h{c=3}}\n",
+		},
+		{
+			name:     "Together",
+			together: true,
+			give: []any{
+				slog.Group("a", "x", 1), "k", "v", slog.Group("a", "y", 2),
+			},
+			want: "INF msg  a{x=1 y=2} k=v\n",
+		},
+		{
+			name: "Multiline",
+			give: []any{
+				slog.Group("g", "a", 1, "b", "foo\nbar", "c", 3),
+			},
+			want: "INF msg  g{a=1}\n" +
+				"  g.b=\n" +
+				"    | foo\n" +
+				"    | bar\n" +
+				"  g{c=3}\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buffer strings.Builder
+			log := slog.New(silog.NewHandler(&buffer, &silog.HandlerOptions{
+				Style:              silog.PlainStyle(),
+				ReplaceAttr:        skipTime,
+				GroupBraces:        true,
+				GroupAttrsTogether: tt.together,
+			}))
+			if tt.build != nil {
+				log = tt.build(log)
+			}
+
+			log.Info("msg", tt.give...)
+			assert.Equal(t, tt.want, buffer.String())
+		})
+	}
+}
+
+func TestHandler_singleLineMessages(t *testing.T) {
+	tests := []struct {
+		name       string
+		singleLine bool
+		want       string
+	}{
+		{
+			name: "Default",
+			want: "INF foo\n" +
+				"INF bar  \n" +
+				"  k=\n" +
+				"    | v1\n" +
+				"    | v2\n",
+		},
+		{
+			name:       "SingleLine",
+			singleLine: true,
+			want: `INF foo\nbar  ` + "\n" +
+				"  k=\n" +
+				"    | v1\n" +
+				"    | v2\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buffer strings.Builder
+			log := slog.New(silog.NewHandler(&buffer, &silog.HandlerOptions{
+				Style:              silog.PlainStyle(),
+				ReplaceAttr:        skipTime,
+				SingleLineMessages: tt.singleLine,
+			}))
+
+			log.Info("foo\nbar", "k", "v1\nv2")
+			assert.Equal(t, tt.want, buffer.String())
+		})
+	}
+}
+
+func TestHandler_trailingNewlineMessageWithAttrs(t *testing.T) {
+	tests := []struct {
+		name  string
+		style *silog.Style
+		give  func(*slog.Logger)
+		want  string
+	}{
+		{
+			name:  "Plain",
+			style: silog.PlainStyle(),
+			give:  func(log *slog.Logger) { log.Info("foo\n", "k", "v") },
+			want:  "INF foo\n  k=v\n",
+		},
+		{
+			name:  "PlainWithAttrs",
+			style: silog.PlainStyle(),
+			give:  func(log *slog.Logger) { log.With("a", 1).Info("foo\n", "k", "v") },
+			want:  "INF foo\n  a=1 k=v\n",
+		},
+		{
+			name:  "PlainMultilineAttr",
+			style: silog.PlainStyle(),
+			give:  func(log *slog.Logger) { log.Info("foo\n", "k", "v1\nv2") },
+			want:  "INF foo\n  k=\n    | v1\n    | v2\n",
+		},
+		{
+			name:  "Styled",
+			style: silog.DefaultStyle(),
+			give:  func(log *slog.Logger) { log.Info("foo\n", "k", "v") },
+			want: "\x1b[92mINF\x1b[m foo\n" +
+				"  \x1b[2mk\x1b[m\x1b[2m=\x1b[mv\n",
+		},
+		{
+			name:  "StyledWithAttrs",
+			style: silog.DefaultStyle(),
+			give:  func(log *slog.Logger) { log.With("a", 1).Info("foo\n", "k", "v") },
+			want: "\x1b[92mINF\x1b[m foo\n" +
+				"  \x1b[2ma\x1b[m\x1b[2m=\x1b[m1 \x1b[2mk\x1b[m\x1b[2m=\x1b[mv\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buffer strings.Builder
+			log := slog.New(silog.NewHandler(&buffer, &silog.HandlerOptions{
+				Style:       tt.style,
+				ReplaceAttr: skipTime,
+			}))
+			tt.give(log)
+			assert.Equal(t, tt.want, buffer.String())
+		})
+	}
+}
+
+func TestHandler_groupDepthThreshold(t *testing.T) {
+	tests := []struct {
+		name      string
+		threshold int
+		want      []string
+	}{
+		{
+			name: "Disabled",
+			want: []string{
+				"INF msg  x=1 a.y=2 a.b.c.d.k1=3 a.b.c.d.k2=4 a.b.c.e.k3=5 z=6",
+			},
+		},
+		{
+			name:      "AtDepth",
+			threshold: 4,
+			want: []string{
+				"INF msg  x=1 a.y=2 a.b.c.d.k1=3 a.b.c.d.k2=4 a.b.c.e.k3=5 z=6",
+			},
+		},
+		{
+			name:      "BelowDepth",
+			threshold: 3,
+			want: []string{
+				"INF msg  x=1 a.y=2",
+				"  a.b.c:",
+				"    d:",
+				"      k1=3",
+				"      k2=4",
+				"    e:",
+				"      k3=5",
+				"  z=6",
+			},
+		},
+		{
+			name:      "Shallow",
+			threshold: 1,
+			want: []string{
+				"INF msg  x=1 a.y=2",
+				"  a:",
+				"    b:",
+				"      c:",
+				"        d:",
+				"          k1=3",
+				"          k2=4",
+				"        e:",
+				"          k3=5",
+				"  z=6",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buffer strings.Builder
+			handler := silog.NewHandler(&buffer, &silog.HandlerOptions{
+				Style:               silog.PlainStyle(),
+				ReplaceAttr:         skipTime,
+				GroupDepthThreshold: tt.threshold,
+			})
+
+			slog.New(handler).Info("msg",
+				"x", 1,
+				slog.Group("a",
+					"y", 2,
+					slog.Group("b", slog.Group("c",
+						slog.Group("d", "k1", 3, "k2", 4),
+						slog.Group("e", "k3", 5),
+					)),
+				),
+				"z", 6,
+			)
+
+			assert.Equal(t, strings.Join(tt.want, "\n")+"\n", buffer.String())
+		})
+	}
+
+	t.Run("MultilineValue", func(t *testing.T) {
+		var buffer strings.Builder
+		handler := silog.NewHandler(&buffer, &silog.HandlerOptions{
+			Style:               silog.PlainStyle(),
+			ReplaceAttr:         skipTime,
+			GroupDepthThreshold: 1,
+		})
+
+		slog.New(handler).WithGroup("a").WithGroup("b").Info("msg", "k", "foo\nbar")
+		assert.Equal(t,
+			"INF msg  \n"+
+				"  a:\n"+
+				"    b:\n"+
+				"      k=\n"+
+				"        | foo\n"+
+				"        | bar\n",
+			buffer.String())
+	})
+}
+
+func TestHandler_verboseAttrs(t *testing.T) {
+	var buffer strings.Builder
+	handler := silog.NewHandler(&buffer, &silog.HandlerOptions{
+		Style:        silog.PlainStyle(),
+		ReplaceAttr:  skipTime,
+		VerboseAttrs: true,
+		GroupBraces:  true, // ignored
+	})
+	log := slog.New(handler).With("method", "GET")
+
+	log.Info("Request finished",
+		"path", "/",
+		slog.Group("req", "headers", "Accept: */*\nHost: example.com"),
+		"status", 200,
+	)
+	log.Info("multi\nline")
+	slog.New(handler).Info("no attrs")
+
+	assert.Equal(t,
+		"INF Request finished\n"+
+			"       method=GET\n"+
+			"         path=/\n"+
+			"  req.headers=\n"+
+			"      | Accept: */*\n"+
+			"      | Host: example.com\n"+
+			"       status=200\n"+
+			"INF multi\n"+
+			"INF line\n"+
+			"  method=GET\n"+
+			"INF no attrs\n",
+		buffer.String())
+}
+
+func TestHandler_metadataColumnWidth(t *testing.T) {
+	tests := []struct {
+		name       string
+		profile    colorprofile.Profile
+		indentCont bool
+		want       []string
+	}{
+		{
+			name: "Rule",
+			want: []string{
+				"INF db: │ Connected  k=v",
+				"WRN     │ multi",
+				"WRN     │ line",
+				"ERR verylongprefix: │ wide",
+			},
+		},
+		{
+			name:       "IndentContinuationLines",
+			indentCont: true,
+			want: []string{
+				"INF db: │ Connected  k=v",
+				"WRN     │ multi",
+				"        │ line",
+				"ERR verylongprefix: │ wide",
+			},
+		},
+		{
+			name:    "Ascii",
+			profile: colorprofile.Ascii,
+			want: []string{
+				"INF db:   Connected  k=v",
+				"WRN       multi",
+				"WRN       line",
+				"ERR verylongprefix:   wide",
+			},
+		},
+		{
+			name:    "NoTTY",
+			profile: colorprofile.NoTTY,
+			want: []string{
+				"INF db:   Connected  k=v",
+				"WRN       multi",
+				"WRN       line",
+				"ERR verylongprefix:   wide",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buffer strings.Builder
+			handler := silog.NewHandler(&buffer, &silog.HandlerOptions{
+				Style:                   silog.PlainStyle(),
+				ReplaceAttr:             skipTime,
+				ColorProfile:            tt.profile,
+				IndentContinuationLines: tt.indentCont,
+				MetadataColumnWidth:     8,
+			})
+
+			slog.New(handler.WithPrefix("db")).Info("Connected", "k", "v")
+			slog.New(handler).Warn("multi\nline")
+			slog.New(handler.WithPrefix("verylongprefix")).Error("wide")
+
+			assert.Equal(t, strings.Join(tt.want, "\n")+"\n", buffer.String())
+		})
+	}
+}
+
+func TestHandler_WithGroupLevel(t *testing.T) {
+	var buffer strings.Builder
+	handler := silog.NewHandler(&buffer, &silog.HandlerOptions{
+		Level:       slog.LevelDebug,
+		Style:       silog.PlainStyle(),
+		ReplaceAttr: skipTime,
+	})
+
+	db := handler.WithGroupLevel("db", slog.LevelWarn)
+	query := db.WithGroupLevel("query", slog.LevelInfo)
+
+	ctx := t.Context()
+	assert.True(t, handler.Enabled(ctx, slog.LevelDebug))
+	assert.False(t, db.Enabled(ctx, slog.LevelInfo))
+	assert.True(t, query.Enabled(ctx, slog.LevelInfo), "most specific level")
+	assert.False(t, query.Enabled(ctx, slog.LevelDebug))
+
+	slog.New(handler).Debug("foo", slog.Group("db", "k", 1))
+	slog.New(db).Info("bar", "k", 2)
+	slog.New(db).Warn("baz", "k", 3)
+	slog.New(query).Info("qux", "k", 4)
+
+	assert.Equal(t,
+		"DBG foo  db.k=1\n"+
+			"WRN baz  db.k=3\n"+
+			"INF qux  db.query.k=4\n",
+		buffer.String())
+}
+
+func TestHandler_WithGroupLevel_levelFunc(t *testing.T) {
+	handler := silog.NewHandler(io.Discard, nil).
+		WithLevelFunc(func(context.Context, slog.Level) bool { return true })
+	db := handler.WithGroupLevel("db", slog.LevelWarn)
+
+	ctx := t.Context()
+	assert.True(t, handler.Enabled(ctx, slog.LevelDebug))
+	assert.False(t, db.Enabled(ctx, slog.LevelInfo), "group level replaces level func")
+	assert.True(t, db.Enabled(ctx, slog.LevelWarn))
+}
+
+func TestHandler_WithLevel_levelVar(t *testing.T) {
+	var buffer strings.Builder
+	handler := silog.NewHandler(&buffer, &silog.HandlerOptions{
+		Style:       silog.PlainStyle(),
+		ReplaceAttr: skipTime,
+	})
+
+	var lvl slog.LevelVar
+	lvl.Set(slog.LevelWarn)
+
+	base := handler.WithLevel(&lvl)
+	derived := base.WithPrefix("derived").WithAttrs([]slog.Attr{slog.Int("k", 1)}).(*silog.Handler)
+	offset := base.WithLevelOffset(-4)
+
+	ctx := t.Context()
+	assert.False(t, base.Enabled(ctx, slog.LevelInfo))
+	assert.False(t, derived.Enabled(ctx, slog.LevelInfo))
+	assert.False(t, offset.Enabled(ctx, slog.LevelWarn), "WRN downgraded to INF")
+	assert.True(t, offset.Enabled(ctx, slog.LevelError), "ERR downgraded to WRN")
+
+	lvl.Set(slog.LevelDebug)
+	assert.True(t, base.Enabled(ctx, slog.LevelDebug))
+	assert.True(t, derived.Enabled(ctx, slog.LevelDebug))
+	assert.True(t, offset.Enabled(ctx, slog.LevelInfo), "INF downgraded to DBG")
+	assert.False(t, offset.Enabled(ctx, slog.LevelDebug), "DBG downgraded below DBG")
+
+	// Handlers derived after the change see the same leveler.
+	assert.True(t, offset.WithLevelOffset(4).Enabled(ctx, slog.LevelDebug))
+
+	slog.New(offset).Info("foo")
+	slog.New(derived).Debug("bar")
+	assert.Equal(t, "DBG foo\nDBG derived: bar  k=1\n", buffer.String())
+
+	lvl.Set(slog.LevelError)
+	assert.False(t, derived.Enabled(ctx, slog.LevelWarn))
+	assert.False(t, offset.Enabled(ctx, slog.LevelError+3))
+	assert.True(t, offset.Enabled(ctx, slog.LevelError+4))
+
+	// The original handler is unaffected.
+	assert.True(t, handler.Enabled(ctx, slog.LevelInfo))
+}
+
+type testTextMarshaler struct{ v string }
+
+func (m testTextMarshaler) MarshalText() ([]byte, error) {
+	if m.v == "" {
+		return nil, errors.New("empty value")
+	}
+	return []byte("text:" + m.v), nil
+}
+
+type testStringTextMarshaler struct{ v string }
+
+func (m testStringTextMarshaler) String() string { return "string:" + m.v }
+
+func (m testStringTextMarshaler) MarshalText() ([]byte, error) {
+	return []byte("text:" + m.v), nil
+}
+
+// writerFunc is an io.Writer implemented by a function.
+type writerFunc func([]byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) {
+	return f(p)
+}
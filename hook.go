@@ -0,0 +1,49 @@
+package silog
+
+import (
+	"log/slog"
+	"sync"
+)
+
+// LevelHook is a function that [Handler] calls
+// when it logs a record at or above a level,
+// e.g. to send a notification on errors.
+//
+// Use it with HandlerOptions.LevelHook.
+type LevelHook struct {
+	// Level is the minimum level of records that trigger the hook,
+	// after any level offset (see [Handler.WithLevelOffset]).
+	Level slog.Level
+
+	// Func is called with the record after it has been written,
+	// outside of the handler's locks.
+	// It receives the record as passed to Handle.
+	// It must call slog.Record.Clone if it retains the record
+	// after returning.
+	Func func(rec slog.Record)
+
+	// Once, if set, calls Func only for the first such record.
+	// This is shared by all handlers derived from the same Handler.
+	Once bool
+}
+
+// levelHook is the state of a LevelHook,
+// shared by a Handler and handlers derived from it.
+type levelHook struct {
+	LevelHook
+
+	once sync.Once
+}
+
+// run calls the hook for a record that was written at lvl.
+func (h *levelHook) run(lvl slog.Level, rec slog.Record) {
+	if lvl < h.Level {
+		return
+	}
+
+	if h.Once {
+		h.once.Do(func() { h.Func(rec) })
+	} else {
+		h.Func(rec)
+	}
+}
@@ -0,0 +1,82 @@
+package silog_test
+
+import (
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.abhg.dev/log/silog"
+)
+
+func TestHandler_levelHook(t *testing.T) {
+	var (
+		buffer strings.Builder
+		msgs   []string
+		log    *slog.Logger
+	)
+	log = slog.New(silog.NewHandler(&buffer, &silog.HandlerOptions{
+		Style:       silog.PlainStyle(),
+		ReplaceAttr: skipTime,
+		LevelHook: silog.LevelHook{
+			Level: slog.LevelWarn,
+			Func: func(rec slog.Record) {
+				msgs = append(msgs, rec.Message)
+				// Logging from the hook must not deadlock.
+				log.Info("hook ran")
+			},
+		},
+	}))
+
+	log.Info("foo")
+	log.Warn("bar")
+	log.With("k", "v").Error("baz")
+
+	assert.Equal(t, []string{"bar", "baz"}, msgs)
+	assert.Equal(t,
+		"INF foo\n"+
+			"WRN bar\n"+
+			"INF hook ran\n"+
+			"ERR baz  k=v\n"+
+			"INF hook ran\n",
+		buffer.String())
+}
+
+func TestHandler_levelHook_once(t *testing.T) {
+	var msgs []string
+	handler := silog.NewHandler(&strings.Builder{}, &silog.HandlerOptions{
+		LevelHook: silog.LevelHook{
+			Level: slog.LevelError,
+			Func: func(rec slog.Record) {
+				msgs = append(msgs, rec.Message)
+			},
+			Once: true,
+		},
+	})
+
+	log := slog.New(handler)
+	log.Error("foo")
+	log.With("k", "v").Error("bar")
+	slog.New(handler.WithPrefix("sub")).Error("baz")
+
+	assert.Equal(t, []string{"foo"}, msgs)
+}
+
+func TestHandler_levelHook_levelOffset(t *testing.T) {
+	var levels []slog.Level
+	handler := silog.NewHandler(&strings.Builder{}, &silog.HandlerOptions{
+		LevelHook: silog.LevelHook{
+			Level: slog.LevelError,
+			Func: func(rec slog.Record) {
+				levels = append(levels, rec.Level)
+			},
+		},
+	})
+
+	// The hook level applies after the offset,
+	// but the hook receives the original record.
+	slog.New(handler.WithLevelOffset(-4)).Error("foo")
+	slog.New(handler.WithLevelOffset(4)).Warn("bar")
+
+	assert.Equal(t, []slog.Level{slog.LevelWarn}, levels)
+}
@@ -0,0 +1,46 @@
+package silog
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// MessageCase specifies how [Handler] changes the case
+// of the first letter of log messages.
+type MessageCase int
+
+const (
+	// MessageCaseAsIs leaves messages unchanged.
+	MessageCaseAsIs MessageCase = iota
+
+	// MessageCaseLowerFirst lowercases the first letter of messages,
+	// e.g. "Connected" becomes "connected".
+	MessageCaseLowerFirst
+
+	// MessageCaseUpperFirst uppercases the first letter of messages,
+	// e.g. "connected" becomes "Connected".
+	MessageCaseUpperFirst
+)
+
+// apply returns msg with the case of its first rune changed.
+func (c MessageCase) apply(msg string) string {
+	var toCase func(rune) rune
+	switch c {
+	case MessageCaseLowerFirst:
+		toCase = unicode.ToLower
+	case MessageCaseUpperFirst:
+		toCase = unicode.ToUpper
+	default:
+		return msg
+	}
+
+	r, size := utf8.DecodeRuneInString(msg)
+	if r == utf8.RuneError {
+		// Empty or invalid UTF-8.
+		return msg
+	}
+	if cased := toCase(r); cased != r {
+		return string(cased) + msg[size:]
+	}
+	return msg
+}
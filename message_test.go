@@ -0,0 +1,43 @@
+package silog_test
+
+import (
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.abhg.dev/log/silog"
+)
+
+func TestHandler_messageCase(t *testing.T) {
+	tests := []struct {
+		name string
+		give silog.MessageCase
+		msg  string
+		want string
+	}{
+		{name: "AsIs", give: silog.MessageCaseAsIs, msg: "Foo bar", want: "INF Foo bar\n"},
+		{name: "LowerFirst", give: silog.MessageCaseLowerFirst, msg: "Foo Bar", want: "INF foo Bar\n"},
+		{name: "UpperFirst", give: silog.MessageCaseUpperFirst, msg: "foo bar", want: "INF Foo bar\n"},
+		{name: "Unchanged", give: silog.MessageCaseLowerFirst, msg: "foo", want: "INF foo\n"},
+		{name: "Empty", give: silog.MessageCaseUpperFirst, msg: "", want: "\n"},
+		{name: "MultiByte", give: silog.MessageCaseUpperFirst, msg: "éclair", want: "INF Éclair\n"},
+		{name: "NotLetter", give: silog.MessageCaseUpperFirst, msg: "42 items", want: "INF 42 items\n"},
+		{name: "InvalidUTF8", give: silog.MessageCaseUpperFirst, msg: "\xffoo", want: "INF \xffoo\n"},
+		{name: "MultiLine", give: silog.MessageCaseUpperFirst, msg: "foo\nbar", want: "INF Foo\nINF bar\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buffer strings.Builder
+			log := slog.New(silog.NewHandler(&buffer, &silog.HandlerOptions{
+				Style:       silog.PlainStyle(),
+				ReplaceAttr: skipTime,
+				MessageCase: tt.give,
+			}))
+
+			log.Info(tt.msg)
+			assert.Equal(t, tt.want, buffer.String())
+		})
+	}
+}
@@ -0,0 +1,297 @@
+package silog
+
+import (
+	"bufio"
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// Record is a log record read by [Parser].
+type Record struct {
+	// Time is the time of the record,
+	// or the zero value if the record has no time.
+	Time time.Time
+
+	// Level is the level of the record.
+	Level slog.Level
+
+	// Prefix is the prefix of the record (see [Handler.WithPrefix]).
+	// This is empty unless ParserOptions.Prefixes is set.
+	Prefix string
+
+	// Message is the log message.
+	Message string
+
+	// Attrs are the attributes of the record in the order they appear.
+	// All values are strings.
+	// Attributes with dotted keys (e.g. "req.id") are placed in groups.
+	Attrs []slog.Attr
+}
+
+// ParserOptions defines options for a [Parser].
+type ParserOptions struct {
+	// TimeFormat is the format of timestamps in the input.
+	// If unset, time.Kitchen is used, matching [HandlerOptions].
+	TimeFormat string // optional
+
+	// Prefixes, if set, specifies that messages may have prefixes,
+	// and that text up to the first ": " of a message
+	// is a prefix if it contains no spaces.
+	//
+	// By default, prefixes are considered part of the message.
+	Prefixes bool // optional
+
+	// Style is the style the input was written with.
+	// Levels are parsed with [Style.ParseLevel],
+	// so custom levels added with [Style.RegisterLevel] are supported.
+	// If unset, PlainStyle is used.
+	Style *Style // optional
+}
+
+// Parser reads log records written by a [Handler]
+// back into their components.
+// Use it to build tools on top of silog output,
+// or to verify the output of a Handler in tests.
+//
+// Parser only supports output written with [PlainStyle]
+// or another style that does not add escape codes,
+// and with the default delimiters.
+// The format is meant for humans, so some output is ambiguous:
+//
+//   - the message ends at the first two consecutive spaces
+//   - words without a "=" following an attribute
+//     are considered part of its value
+//   - lines of multi-line messages are read as separate records
+//     unless HandlerOptions.IndentContinuationLines was set
+type Parser struct {
+	r    *bufio.Reader
+	opts ParserOptions
+
+	// next is the next line, read ahead to find
+	// the continuation lines of a record.
+	next    string
+	hasNext bool
+	lineNum int // number of the last line read
+
+	// err is the error that stopped reading, if any.
+	// It's io.EOF at the end of the input.
+	err error
+}
+
+// NewParser builds a Parser that reads log records from r.
+func NewParser(r io.Reader, opts *ParserOptions) *Parser {
+	opts = cmp.Or(opts, &ParserOptions{})
+	p := &Parser{
+		r:    bufio.NewReader(r),
+		opts: *opts,
+	}
+	p.opts.TimeFormat = cmp.Or(p.opts.TimeFormat, time.Kitchen)
+	p.opts.Style = cmp.Or(p.opts.Style, PlainStyle())
+	return p
+}
+
+// Next reads the next record from the input.
+// It returns io.EOF if there are no more records,
+// or the error from the underlying reader if reading failed.
+func (p *Parser) Next() (*Record, error) {
+	var line string
+	for line == "" {
+		var ok bool
+		line, ok = p.readLine()
+		if !ok {
+			if errors.Is(p.err, io.EOF) {
+				return nil, io.EOF
+			}
+			return nil, fmt.Errorf("line %d: %w", p.lineNum+1, p.err)
+		}
+	}
+
+	var rec Record
+	lineNum := p.lineNum
+	rest, err := p.parseHead(line, &rec)
+	if err != nil {
+		return nil, fmt.Errorf("line %d: %w", lineNum, err)
+	}
+	headWidth := len(line) - len(rest)
+
+	msg, attrText, _ := strings.Cut(rest, msgAttrDelim)
+	var msgLines []string
+	msgLines = append(msgLines, msg)
+	if p.opts.Prefixes {
+		prefix, msg, ok := strings.Cut(msgLines[0], ": ")
+		if ok && prefix != "" && !strings.Contains(prefix, " ") {
+			rec.Prefix = prefix
+			msgLines[0] = msg
+		}
+	}
+
+	var attrs attrParser
+	attrs.parse(attrText)
+
+	// Continuation lines are indented.
+	// If the indentation matches the head of the first line,
+	// it's a continuation of the message.
+	// Otherwise, it holds attributes or lines of a multi-line value.
+	contIndent := strings.Repeat(" ", headWidth)
+	for {
+		line, ok := p.peekLine()
+		if !ok || !strings.HasPrefix(line, indent) {
+			break
+		}
+		p.readLine()
+
+		switch {
+		case strings.HasPrefix(line, indent+multilinePrefix):
+			attrs.addLine(strings.TrimPrefix(line, indent+multilinePrefix))
+		case headWidth > len(indent) && len(attrs.attrs) == 0 && strings.HasPrefix(line, contIndent):
+			msg, attrText, _ := strings.Cut(line[headWidth:], msgAttrDelim)
+			msgLines = append(msgLines, msg)
+			attrs.parse(attrText)
+		default:
+			attrs.parse(line[len(indent):])
+		}
+	}
+
+	rec.Message = strings.Join(msgLines, "\n")
+	for _, kv := range attrs.attrs {
+		rec.Attrs = insertAttr(rec.Attrs, strings.Split(kv.key, groupDelim), kv.value)
+	}
+	return &rec, nil
+}
+
+// multilinePrefix is the prefix of lines of multi-line values
+// in PlainStyle, after the indentation.
+const multilinePrefix = "  | "
+
+// parseHead parses the time and level at the start of a line into rec,
+// and returns the rest of the line.
+func (p *Parser) parseHead(line string, rec *Record) (string, error) {
+	// The time format may contain spaces.
+	numFields := strings.Count(p.opts.TimeFormat, " ") + 1
+	if fields := strings.SplitN(line, timeDelim, numFields+1); len(fields) > numFields {
+		timestr := strings.Join(fields[:numFields], timeDelim)
+		if t, err := time.Parse(p.opts.TimeFormat, timestr); err == nil {
+			rec.Time = t
+			line = fields[numFields]
+		}
+	}
+
+	lvlstr, rest, _ := strings.Cut(line, lvlDelim)
+	lvl, err := p.opts.Style.ParseLevel(lvlstr)
+	if err != nil {
+		return "", err
+	}
+	rec.Level = lvl
+	return rest, nil
+}
+
+// attrParser accumulates the key-value pairs of a record.
+type attrParser struct {
+	attrs []parsedAttr
+
+	// multiline is set if the last attribute has lines.
+	multiline bool
+}
+
+type parsedAttr struct {
+	key, value string
+}
+
+// parse parses space-separated key=value pairs.
+// Words without a "=" are appended to the previous value.
+func (a *attrParser) parse(s string) {
+	if s == "" {
+		return
+	}
+
+	for word := range strings.SplitSeq(s, attrDelim) {
+		key, value, ok := strings.Cut(word, "=")
+		if !ok || key == "" {
+			if n := len(a.attrs); n > 0 && !a.multiline {
+				a.attrs[n-1].value += attrDelim + word
+			}
+			continue
+		}
+
+		a.attrs = append(a.attrs, parsedAttr{key: key, value: value})
+		a.multiline = false
+	}
+}
+
+// addLine adds a line to the value of the last attribute.
+func (a *attrParser) addLine(line string) {
+	n := len(a.attrs)
+	if n == 0 {
+		return
+	}
+
+	if a.multiline {
+		a.attrs[n-1].value += "\n" + line
+	} else {
+		a.attrs[n-1].value = line
+		a.multiline = true
+	}
+}
+
+// insertAttr adds a string attribute to attrs
+// inside the groups in path, creating them if necessary.
+// The last element of path is the attribute key.
+func insertAttr(attrs []slog.Attr, path []string, value string) []slog.Attr {
+	if len(path) == 1 {
+		return append(attrs, slog.String(path[0], value))
+	}
+
+	for i, attr := range attrs {
+		if attr.Key == path[0] && attr.Value.Kind() == slog.KindGroup {
+			members := insertAttr(attr.Value.Group(), path[1:], value)
+			attrs[i].Value = slog.GroupValue(members...)
+			return attrs
+		}
+	}
+
+	members := insertAttr(nil, path[1:], value)
+	return append(attrs, slog.Attr{Key: path[0], Value: slog.GroupValue(members...)})
+}
+
+// readLine returns the next line without its trailing newline.
+// It reports false if there are no more lines
+// or reading failed, recording the reason in p.err.
+func (p *Parser) readLine() (string, bool) {
+	if p.hasNext {
+		p.hasNext = false
+		p.lineNum++
+		return p.next, true
+	}
+	if p.err != nil {
+		return "", false
+	}
+
+	line, err := p.r.ReadString('\n')
+	if err != nil {
+		// A partial line before the error is still returned.
+		p.err = err
+	}
+	if line == "" && err != nil {
+		return "", false
+	}
+	p.lineNum++
+	return strings.TrimRight(line, "\r\n"), true
+}
+
+// peekLine returns the next line without consuming it.
+func (p *Parser) peekLine() (string, bool) {
+	if !p.hasNext {
+		line, ok := p.readLine()
+		if !ok {
+			return "", false
+		}
+		p.lineNum--
+		p.next, p.hasNext = line, true
+	}
+	return p.next, true
+}
@@ -0,0 +1,192 @@
+package silog_test
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.abhg.dev/log/silog"
+)
+
+func TestParser_roundTrip(t *testing.T) {
+	now := time.Date(2025, 1, 2, 15, 4, 5, 678_000_000, time.UTC)
+
+	var buffer strings.Builder
+	handler := silog.NewHandler(&buffer, &silog.HandlerOptions{
+		Level:                   slog.LevelDebug,
+		Style:                   silog.PlainStyle(),
+		TimeFormat:              silog.TimeFormatISO,
+		IndentContinuationLines: true,
+		ReplaceAttr: func(groups []string, attr slog.Attr) slog.Attr {
+			if len(groups) == 0 && attr.Key == slog.TimeKey {
+				return slog.Time(slog.TimeKey, now)
+			}
+			return attr
+		},
+	})
+
+	log := slog.New(handler)
+	log.Info("hello", "k", "v", "n", 42)
+	log.Debug("no attrs")
+	slog.New(handler.WithPrefix("app")).Warn("starting: now", "port", 8080)
+	log.WithGroup("req").Error("failed",
+		"id", "abc",
+		slog.Group("user", "name", "Jane Doe", "id", 1),
+		"error", "bad thing\nhappened here",
+		"k", "v",
+	)
+	log.Info("multi\nline message", "k", "v")
+
+	p := silog.NewParser(strings.NewReader(buffer.String()), &silog.ParserOptions{
+		TimeFormat: silog.TimeFormatISO,
+		Prefixes:   true,
+	})
+	var got []*silog.Record
+	for {
+		rec, err := p.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+		assert.True(t, now.Equal(rec.Time), "time: %v", rec.Time)
+		rec.Time = time.Time{}
+		got = append(got, rec)
+	}
+
+	assert.Equal(t, []*silog.Record{
+		{
+			Level:   slog.LevelInfo,
+			Message: "hello",
+			Attrs:   []slog.Attr{slog.String("k", "v"), slog.String("n", "42")},
+		},
+		{
+			Level:   slog.LevelDebug,
+			Message: "no attrs",
+		},
+		{
+			Level:   slog.LevelWarn,
+			Prefix:  "app",
+			Message: "starting: now",
+			Attrs:   []slog.Attr{slog.String("port", "8080")},
+		},
+		{
+			Level:   slog.LevelError,
+			Message: "failed",
+			Attrs: []slog.Attr{
+				slog.Group("req",
+					slog.String("id", "abc"),
+					slog.Group("user",
+						slog.String("name", "Jane Doe"),
+						slog.String("id", "1"),
+					),
+					slog.String("error", "bad thing\nhappened here"),
+					slog.String("k", "v"),
+				),
+			},
+		},
+		{
+			Level:   slog.LevelInfo,
+			Message: "multi\nline message",
+			Attrs:   []slog.Attr{slog.String("k", "v")},
+		},
+	}, got)
+}
+
+func TestParser_noTime(t *testing.T) {
+	p := silog.NewParser(strings.NewReader("INF foo  k=v\n\nWRN bar\n"), nil)
+
+	rec, err := p.Next()
+	require.NoError(t, err)
+	assert.Equal(t, &silog.Record{
+		Level:   slog.LevelInfo,
+		Message: "foo",
+		Attrs:   []slog.Attr{slog.String("k", "v")},
+	}, rec)
+
+	rec, err = p.Next()
+	require.NoError(t, err)
+	assert.Equal(t, &silog.Record{Level: slog.LevelWarn, Message: "bar"}, rec)
+
+	_, err = p.Next()
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestParser_unknownLevel(t *testing.T) {
+	p := silog.NewParser(strings.NewReader("INF foo\nnot a log line\n"), nil)
+
+	_, err := p.Next()
+	require.NoError(t, err)
+
+	_, err = p.Next()
+	assert.ErrorContains(t, err, `line 2: unknown level: "not"`)
+}
+
+func TestParser_readError(t *testing.T) {
+	readErr := errors.New("great sadness")
+	p := silog.NewParser(io.MultiReader(
+		strings.NewReader("INF foo\nWRN bar"),
+		iotest.ErrReader(readErr),
+	), nil)
+
+	rec, err := p.Next()
+	require.NoError(t, err)
+	assert.Equal(t, "foo", rec.Message)
+
+	// The partial line before the error is still a record.
+	rec, err = p.Next()
+	require.NoError(t, err)
+	assert.Equal(t, "bar", rec.Message)
+
+	_, err = p.Next()
+	assert.ErrorIs(t, err, readErr)
+	assert.NotErrorIs(t, err, io.EOF)
+
+	// The error sticks.
+	_, err = p.Next()
+	assert.ErrorIs(t, err, readErr)
+}
+
+func TestParser_customLevels(t *testing.T) {
+	const levelTrace = slog.LevelDebug - 4
+	style := silog.PlainStyle().RegisterLevel(levelTrace, "TRACE", "TRC", nil)
+
+	var buffer strings.Builder
+	log := slog.New(silog.NewHandler(&buffer, &silog.HandlerOptions{
+		Level:       levelTrace,
+		Style:       style,
+		ReplaceAttr: skipTime,
+	}))
+	log.Log(t.Context(), levelTrace, "foo", "k", "v")
+	log.Warn("bar")
+
+	p := silog.NewParser(strings.NewReader(buffer.String()), &silog.ParserOptions{
+		Style: style,
+	})
+
+	rec, err := p.Next()
+	require.NoError(t, err)
+	assert.Equal(t, &silog.Record{
+		Level:   levelTrace,
+		Message: "foo",
+		Attrs:   []slog.Attr{slog.String("k", "v")},
+	}, rec)
+
+	rec, err = p.Next()
+	require.NoError(t, err)
+	assert.Equal(t, &silog.Record{Level: slog.LevelWarn, Message: "bar"}, rec)
+
+	_, err = p.Next()
+	assert.ErrorIs(t, err, io.EOF)
+
+	t.Run("DefaultStyle", func(t *testing.T) {
+		p := silog.NewParser(strings.NewReader(buffer.String()), nil)
+		_, err := p.Next()
+		assert.ErrorContains(t, err, `line 1: unknown level: "TRC"`)
+	})
+}
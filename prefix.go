@@ -0,0 +1,55 @@
+package silog
+
+import (
+	"context"
+	"slices"
+	"strings"
+)
+
+// ContextPrefix specifies how a [Handler] uses prefixes
+// added to a context with [ContextWithPrefix].
+type ContextPrefix int
+
+const (
+	// ContextPrefixIgnore ignores prefixes in the context.
+	ContextPrefixIgnore ContextPrefix = iota
+
+	// ContextPrefixReplace uses prefixes in the context
+	// in place of the handler's prefix (see [Handler.WithPrefix]).
+	ContextPrefixReplace
+
+	// ContextPrefixAppend appends prefixes in the context
+	// to the handler's prefix (see [Handler.WithPrefix]).
+	ContextPrefixAppend
+)
+
+// defaultPrefixSeparator joins prefix segments
+// if HandlerOptions.PrefixSeparator is unset.
+const defaultPrefixSeparator = "/"
+
+// prefixKey is the context key for prefix segments.
+type prefixKey struct{}
+
+// ContextWithPrefix returns a copy of ctx with seg added
+// to the end of its prefix segments.
+// Handlers with HandlerOptions.ContextPrefix set
+// join these segments into the prefix of records logged with the context.
+//
+// For example, middleware may add "request",
+// and a handler further down may add "payment",
+// so that records logged with the handler's context
+// have the prefix "request/payment".
+func ContextWithPrefix(ctx context.Context, seg string) context.Context {
+	segs, _ := ctx.Value(prefixKey{}).([]string)
+	// Copy so that contexts derived from the same parent
+	// don't share the backing array.
+	segs = append(slices.Clip(segs), seg)
+	return context.WithValue(ctx, prefixKey{}, segs)
+}
+
+// contextPrefix returns the prefix segments in ctx
+// joined with sep, or an empty string if there are none.
+func contextPrefix(ctx context.Context, sep string) string {
+	segs, _ := ctx.Value(prefixKey{}).([]string)
+	return strings.Join(segs, sep)
+}
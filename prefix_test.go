@@ -0,0 +1,70 @@
+package silog_test
+
+import (
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.abhg.dev/log/silog"
+)
+
+func TestContextWithPrefix(t *testing.T) {
+	tests := []struct {
+		name string
+		mode silog.ContextPrefix
+		sep  string
+		want []string
+	}{
+		{
+			name: "Ignore",
+			want: []string{
+				"INF static: foo",
+				"INF static: bar",
+				"INF static: baz",
+			},
+		},
+		{
+			name: "Replace",
+			mode: silog.ContextPrefixReplace,
+			want: []string{
+				"INF request: foo",
+				"INF request/payment: bar",
+				"INF static: baz",
+			},
+		},
+		{
+			name: "Append",
+			mode: silog.ContextPrefixAppend,
+			sep:  ".",
+			want: []string{
+				"INF static.request: foo",
+				"INF static.request.payment: bar",
+				"INF static: baz",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buffer strings.Builder
+			handler := silog.NewHandler(&buffer, &silog.HandlerOptions{
+				Style:           silog.PlainStyle(),
+				ReplaceAttr:     skipTime,
+				ContextPrefix:   tt.mode,
+				PrefixSeparator: tt.sep,
+			})
+			log := slog.New(handler.WithPrefix("static"))
+
+			ctx := silog.ContextWithPrefix(t.Context(), "request")
+			log.InfoContext(ctx, "foo")
+
+			// Sibling contexts must not affect each other.
+			_ = silog.ContextWithPrefix(ctx, "refund")
+			log.InfoContext(silog.ContextWithPrefix(ctx, "payment"), "bar")
+			log.InfoContext(t.Context(), "baz")
+
+			assert.Equal(t, strings.Join(tt.want, "\n")+"\n", buffer.String())
+		})
+	}
+}
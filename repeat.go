@@ -0,0 +1,76 @@
+package silog
+
+import (
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// defaultRepeatedAttrMarker is the default value of
+// HandlerOptions.RepeatedAttrMarker.
+const defaultRepeatedAttrMarker = "↑"
+
+// repeatCache tracks attribute values of the previous record
+// for HandlerOptions.ElideRepeatedAttrs.
+//
+// It is shared between a handler and handlers derived from it.
+type repeatCache struct {
+	// mu must be held while rendering and writing a record
+	// so that "previous record" is well-defined.
+	mu sync.Mutex
+
+	marker string
+	prev   map[string]string // full key => rendered value
+	cur    map[string]string // values in the current record
+}
+
+func newRepeatCache(marker string) *repeatCache {
+	return &repeatCache{
+		marker: marker,
+		prev:   make(map[string]string),
+		cur:    make(map[string]string),
+	}
+}
+
+// seen records the rendered value of an attribute in the current record,
+// and reports whether the previous record had the same value for it.
+func (c *repeatCache) seen(key string, value []byte) bool {
+	prev, ok := c.prev[key]
+	repeated := ok && prev == string(value)
+	c.cur[key] = string(value)
+	return repeated
+}
+
+// commit finishes the current record,
+// making it the previous record for the next one.
+// Attributes absent from the current record are forgotten.
+func (c *repeatCache) commit() {
+	c.prev, c.cur = c.cur, c.prev
+	clear(c.cur)
+}
+
+// timeCache tracks the formatted time of the previous record
+// for HandlerOptions.ElideRepeatedTime.
+//
+// It is shared between a handler and handlers derived from it.
+type timeCache struct {
+	// mu must be held while rendering and writing a record
+	// so that "previous record" is well-defined.
+	mu sync.Mutex
+
+	prev string
+}
+
+// elide returns spaces the width of the given formatted time
+// if it's the same as the previous record's time,
+// and the time unchanged otherwise.
+func (c *timeCache) elide(t string) string {
+	if t == "" {
+		return ""
+	}
+	if t == c.prev {
+		return strings.Repeat(" ", utf8.RuneCountInString(t))
+	}
+	c.prev = t
+	return t
+}
@@ -0,0 +1,99 @@
+package silog_test
+
+import (
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.abhg.dev/log/silog"
+)
+
+func TestHandler_elideRepeatedAttrs(t *testing.T) {
+	var buffer strings.Builder
+	handler := silog.NewHandler(&buffer, &silog.HandlerOptions{
+		Style:              silog.PlainStyle(),
+		ReplaceAttr:        skipTime,
+		ElideRepeatedAttrs: true,
+	})
+
+	log := slog.New(handler).With("trace_id", "abc")
+	log.Info("request started", "status", 100)
+	log.Info("cache miss", slog.Group("req", "id", 1))
+	log.Info("request done", "status", 100, slog.Group("req", "id", 2))
+	log.Info("no repeat", "id", 2)             // req.id and id differ
+	slog.New(handler).Info("no trace")         // forgets trace_id
+	log.Info("trace again", "out", "foo\nbar") // not repeated
+	log.Info("multi-line repeat", "out", "foo\nbar")
+
+	assert.Equal(t,
+		"INF request started  trace_id=abc status=100\n"+
+			"INF cache miss  trace_id=↑ req.id=1\n"+
+			"INF request done  trace_id=↑ status=100 req.id=2\n"+
+			"INF no repeat  trace_id=↑ id=2\n"+
+			"INF no trace\n"+
+			"INF trace again  trace_id=abc\n"+
+			"  out=\n"+
+			"    | foo\n"+
+			"    | bar\n"+
+			"INF multi-line repeat  trace_id=↑ out=↑\n",
+		buffer.String())
+}
+
+func TestHandler_elideRepeatedAttrs_marker(t *testing.T) {
+	var primary, detail strings.Builder
+	handler := silog.NewHandler(&primary, &silog.HandlerOptions{
+		Style:              silog.PlainStyle(),
+		ReplaceAttr:        skipTime,
+		ElideRepeatedAttrs: true,
+		RepeatedAttrMarker: "^",
+		DetailWriter:       &detail,
+		SummaryKeys:        []string{"k"},
+	})
+
+	log := slog.New(handler)
+	log.Info("foo", "k", "v")
+	log.Info("bar", "k", "v")
+
+	assert.Equal(t, "INF foo  k=v ref=1\nINF bar  k=^ ref=2\n", primary.String())
+	assert.Equal(t, "INF foo  k=v ref=1\nINF bar  k=v ref=2\n", detail.String(),
+		"detail records are not elided")
+}
+
+func TestHandler_elideRepeatedTime(t *testing.T) {
+	times := []time.Time{
+		time.Date(2025, 1, 2, 9, 45, 1, 0, time.UTC),
+		time.Date(2025, 1, 2, 9, 45, 30, 0, time.UTC),
+		time.Date(2025, 1, 2, 9, 46, 0, 0, time.UTC),
+		time.Date(2025, 1, 2, 9, 46, 0, 0, time.UTC),
+		time.Time{}, // no time
+		time.Date(2025, 1, 2, 9, 46, 0, 0, time.UTC),
+	}
+
+	var primary, detail strings.Builder
+	handler := silog.NewHandler(&primary, &silog.HandlerOptions{
+		Style:             silog.PlainStyle(),
+		ElideRepeatedTime: true,
+		DetailWriter:      &detail,
+		SummaryKeys:       []string{},
+	})
+
+	for i, tm := range times {
+		rec := slog.NewRecord(tm, slog.LevelInfo, "msg", 0)
+		require.NoError(t, handler.Handle(t.Context(), rec), "record %d", i)
+	}
+
+	lines := strings.Split(primary.String(), "\n")
+	assert.Equal(t, []string{
+		"9:45AM INF msg  ref=1",
+		"       INF msg  ref=2",
+		"9:46AM INF msg  ref=3",
+		"       INF msg  ref=4",
+		"INF msg  ref=5",
+		"       INF msg  ref=6",
+		"",
+	}, lines)
+	assert.NotContains(t, detail.String(), "       INF", "detail records are not elided")
+}
@@ -0,0 +1,114 @@
+package silog
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// RingWriter is an io.Writer that retains only
+// the most recent log records written to it.
+//
+// Use it as the output of a [Handler]
+// (possibly alongside another writer with io.MultiWriter)
+// to keep a cheap in-memory record of recent logs,
+// and dump them when something goes wrong:
+//
+//	ring := silog.NewRingWriter(100)
+//	handler := silog.NewHandler(io.MultiWriter(os.Stderr, ring), nil)
+//	defer func() {
+//		if r := recover(); r != nil {
+//			ring.Dump(os.Stderr)
+//			panic(r)
+//		}
+//	}()
+//
+// Each call to Write is treated as one record.
+// Handler writes each record with a single Write call,
+// so a multi-line record is retained as a unit.
+//
+// RingWriter is safe for concurrent use.
+type RingWriter struct {
+	mu      sync.Mutex
+	records [][]byte // ring buffer of records
+	next    int      // index in records to write the next record to
+	full    bool     // whether all slots in records are occupied
+}
+
+var _ io.Writer = (*RingWriter)(nil)
+
+// NewRingWriter builds a RingWriter that retains
+// the last n records written to it.
+// n must be at least 1.
+func NewRingWriter(n int) *RingWriter {
+	if n < 1 {
+		panic("silog.NewRingWriter: n must be at least 1")
+	}
+	return &RingWriter{records: make([][]byte, n)}
+}
+
+// Write records p as a single record,
+// discarding the oldest record if the buffer is full.
+//
+// It never fails.
+func (w *RingWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	// The caller may reuse p, so we must copy it.
+	// Reuse the memory of the record we're replacing if possible.
+	w.records[w.next] = append(w.records[w.next][:0], p...)
+	w.next++
+	if w.next == len(w.records) {
+		w.next = 0
+		w.full = true
+	}
+	return len(p), nil
+}
+
+// Records returns the retained records, oldest first.
+// Each element is a whole record as written by one Write call,
+// so a multi-line record is a single element with embedded newlines.
+// Trailing newlines are removed from each record.
+func (w *RingWriter) Records() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var records []string
+	w.each(func(record []byte) {
+		records = append(records, string(bytes.TrimSuffix(record, []byte("\n"))))
+	})
+	return records
+}
+
+// Dump writes the retained records to the given writer, oldest first,
+// in a single Write call.
+//
+// The records are retained after a Dump.
+func (w *RingWriter) Dump(dst io.Writer) error {
+	var buf bytes.Buffer
+	func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+
+		w.each(func(record []byte) {
+			buf.Write(record)
+		})
+	}()
+
+	_, err := dst.Write(buf.Bytes())
+	return err
+}
+
+// each calls fn for each retained record, oldest first.
+// The caller must hold mu.
+func (w *RingWriter) each(fn func([]byte)) {
+	if w.full {
+		for _, record := range w.records[w.next:] {
+			fn(record)
+		}
+	}
+	for _, record := range w.records[:w.next] {
+		fn(record)
+	}
+}
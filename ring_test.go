@@ -0,0 +1,65 @@
+package silog_test
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.abhg.dev/log/silog"
+)
+
+func TestRingWriter(t *testing.T) {
+	ring := silog.NewRingWriter(3)
+	log := slog.New(silog.NewHandler(ring, &silog.HandlerOptions{
+		Style:       silog.PlainStyle(),
+		ReplaceAttr: skipTime,
+	}))
+
+	assert.Empty(t, ring.Records())
+
+	log.Info("one")
+	log.Info("two\ntwo")
+	assert.Equal(t, []string{"INF one", "INF two\nINF two"}, ring.Records())
+
+	log.Info("three")
+	log.Info("four", "k", "v")
+	assert.Equal(t, []string{"INF two\nINF two", "INF three", "INF four  k=v"}, ring.Records())
+
+	var dump strings.Builder
+	require.NoError(t, ring.Dump(&dump))
+	assert.Equal(t, "INF two\nINF two\nINF three\nINF four  k=v\n", dump.String())
+
+	// Dump does not clear the buffer.
+	assert.Len(t, ring.Records(), 3)
+}
+
+func TestRingWriter_concurrent(t *testing.T) {
+	ring := silog.NewRingWriter(10)
+	log := slog.New(silog.NewHandler(ring, &silog.HandlerOptions{
+		Style: silog.PlainStyle(),
+	}))
+
+	var wg sync.WaitGroup
+	for i := range 10 {
+		wg.Go(func() {
+			for j := range 100 {
+				log.Info(fmt.Sprintf("message %d/%d", i, j))
+			}
+		})
+		wg.Go(func() {
+			_ = ring.Dump(new(strings.Builder))
+			_ = ring.Records()
+		})
+	}
+	wg.Wait()
+
+	assert.Len(t, ring.Records(), 10)
+}
+
+func TestNewRingWriter_invalid(t *testing.T) {
+	assert.Panics(t, func() { silog.NewRingWriter(0) })
+}
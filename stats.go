@@ -0,0 +1,74 @@
+package silog
+
+import "sync/atomic"
+
+// HandlerStats reports statistics about records written by a [Handler].
+// Use it to size buffers (see [BufferPool])
+// and to understand log volume.
+//
+// Statistics are collected only if [HandlerOptions.CollectStats] is set.
+type HandlerStats struct {
+	// Records is the number of records handled.
+	Records uint64
+
+	// Bytes is the total size of rendered records in bytes.
+	//
+	// With HandlerOptions.DetailWriter,
+	// this includes both the summary and the detail of each record.
+	Bytes uint64
+
+	// MaxBytes is the size of the largest rendered record in bytes.
+	// Like Bytes, this includes both the summary and the detail
+	// of a record with HandlerOptions.DetailWriter.
+	MaxBytes uint64
+
+	// AvgBytes is the average size of rendered records in bytes,
+	// or zero if no records have been written.
+	AvgBytes float64
+}
+
+// handlerStats holds counters for HandlerStats.
+// It is shared between a handler and handlers derived from it.
+type handlerStats struct {
+	records  atomic.Uint64
+	bytes    atomic.Uint64
+	maxBytes atomic.Uint64
+}
+
+// add records that a record was rendered into the given buffers.
+func (s *handlerStats) add(bufs ...[]byte) {
+	var n uint64
+	for _, buf := range bufs {
+		n += uint64(len(buf))
+	}
+
+	s.records.Add(1)
+	s.bytes.Add(n)
+	for {
+		old := s.maxBytes.Load()
+		if n <= old || s.maxBytes.CompareAndSwap(old, n) {
+			break
+		}
+	}
+}
+
+// Stats returns statistics about records written by this handler,
+// and all handlers that share its output
+// (e.g. those made with WithAttrs, WithPrefix, etc.).
+//
+// It returns zero values if HandlerOptions.CollectStats was not set.
+func (h *Handler) Stats() HandlerStats {
+	if h.stats == nil {
+		return HandlerStats{}
+	}
+
+	stats := HandlerStats{
+		Records:  h.stats.records.Load(),
+		Bytes:    h.stats.bytes.Load(),
+		MaxBytes: h.stats.maxBytes.Load(),
+	}
+	if stats.Records > 0 {
+		stats.AvgBytes = float64(stats.Bytes) / float64(stats.Records)
+	}
+	return stats
+}
@@ -0,0 +1,88 @@
+package silog_test
+
+import (
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.abhg.dev/log/silog"
+)
+
+func TestHandler_Stats(t *testing.T) {
+	var buffer strings.Builder
+	handler := silog.NewHandler(&buffer, &silog.HandlerOptions{
+		Style:        silog.PlainStyle(),
+		ReplaceAttr:  skipTime,
+		CollectStats: true,
+	})
+	assert.Equal(t, silog.HandlerStats{}, handler.Stats())
+
+	log := slog.New(handler)
+	log.Info("foo")                               // "INF foo\n" (8 bytes)
+	log.With("k", "v").Info("foobar")             // "INF foobar  k=v\n" (16 bytes)
+	log.Debug("not logged, not counted")          // below level
+	slog.New(handler.WithPrefix("p")).Info("bar") // "INF p: bar\n"
+
+	assert.Equal(t, silog.HandlerStats{
+		Records:  3,
+		Bytes:    uint64(buffer.Len()),
+		MaxBytes: 16,
+		AvgBytes: float64(buffer.Len()) / 3,
+	}, handler.Stats())
+}
+
+func TestHandler_Stats_disabled(t *testing.T) {
+	var buffer strings.Builder
+	handler := silog.NewHandler(&buffer, &silog.HandlerOptions{
+		Style: silog.PlainStyle(),
+	})
+	slog.New(handler).Info("foo")
+
+	assert.Equal(t, silog.HandlerStats{}, handler.Stats())
+}
+
+func TestHandler_Stats_detailWriter(t *testing.T) {
+	var primary, detail strings.Builder
+	handler := silog.NewHandler(&primary, &silog.HandlerOptions{
+		Style:        silog.PlainStyle(),
+		ReplaceAttr:  skipTime,
+		DetailWriter: &detail,
+		CollectStats: true,
+	})
+	slog.New(handler).Info("foo", "k", "v")
+
+	stats := handler.Stats()
+	assert.Equal(t, uint64(1), stats.Records)
+	assert.Equal(t, uint64(primary.Len()+detail.Len()), stats.Bytes)
+	assert.Equal(t, uint64(primary.Len()+detail.Len()), stats.MaxBytes)
+}
+
+func TestHandler_Stats_concurrent(t *testing.T) {
+	var buffer strings.Builder
+	handler := silog.NewHandler(&buffer, &silog.HandlerOptions{
+		Style:        silog.PlainStyle(),
+		CollectStats: true,
+	})
+
+	const NumWorkers, NumMessages = 10, 100
+
+	var wg sync.WaitGroup
+	wg.Add(NumWorkers)
+	for workerIdx := range NumWorkers {
+		go func() {
+			defer wg.Done()
+
+			logger := slog.New(handler).With("worker", workerIdx)
+			for range NumMessages {
+				logger.Info("Hello")
+			}
+		}()
+	}
+	wg.Wait()
+
+	stats := handler.Stats()
+	assert.Equal(t, uint64(NumWorkers*NumMessages), stats.Records)
+	assert.Equal(t, uint64(buffer.Len()), stats.Bytes)
+}
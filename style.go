@@ -1,7 +1,13 @@
 package silog
 
 import (
+	"fmt"
+	"image/color"
 	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+	"time"
 
 	"charm.land/lipgloss/v2"
 )
@@ -23,6 +29,16 @@ type Style struct {
 	// Key is the style used for the key in key-value pairs.
 	Key lipgloss.Style
 
+	// AttrDelimiter is the style used for the delimiter
+	// separating attributes on the same line.
+	//
+	// It is not written before the first attribute after a message,
+	// or before attributes that start on a new line.
+	//
+	// The default value is " ".
+	// If this is empty, a single space is used.
+	AttrDelimiter lipgloss.Style
+
 	// KeyValueDelimiter is the style used for the delimiter
 	// separating keys and values in key-value pairs.
 	//
@@ -42,6 +58,25 @@ type Style struct {
 	// messages of that level will not be labeled.
 	LevelLabels map[slog.Level]lipgloss.Style
 
+	// LevelName, if set, returns the canonical name of a level
+	// (e.g. "INFO", "TRACE", "INFO+2") for output formats
+	// meant for machines, like FormatTSV.
+	// It's separate from LevelLabels so that the label
+	// shown to humans can differ from the name.
+	//
+	// If unset, these formats use the name from LevelNames,
+	// then the label from LevelLabels,
+	// falling back to slog.Level.String for levels without either.
+	// Neither DefaultStyle nor PlainStyle set this.
+	LevelName func(slog.Level) string
+
+	// LevelNames maps custom levels to their canonical names
+	// (e.g. "TRACE") for output formats meant for machines
+	// (see LevelName) and for [Style.ParseLevel].
+	// Use [Style.RegisterLevel] to fill it.
+	// Neither DefaultStyle nor PlainStyle set this.
+	LevelNames map[slog.Level]string
+
 	// MultilineValuePrefix defines the style for the prefix that is
 	// prepended to each line of an indented multi-line attribute value.
 	//
@@ -49,6 +84,13 @@ type Style struct {
 	// The default value is "| ".
 	MultilineValuePrefix lipgloss.Style
 
+	// NullValue is the style used for attribute values that are nil:
+	// nil interfaces, and typed nil pointers, maps, slices, etc.
+	//
+	// The default value is "null".
+	// If this is empty, "null" is used.
+	NullValue lipgloss.Style
+
 	// PrefixDelimiter defines the style separating a prefix
 	// (specified with Handler.WithPrefix) from the rest of the log message.
 	//
@@ -56,35 +98,323 @@ type Style struct {
 	// The default value is ": ".
 	PrefixDelimiter lipgloss.Style
 
+	// PrefixFormat specifies how prefixes are rendered.
+	// Defaults to PrefixFormatDelimited.
+	PrefixFormat PrefixFormat
+
+	// PrefixOpen and PrefixClose surround prefixes
+	// with PrefixFormatBracketed.
+	// They default to "[" and "]".
+	PrefixOpen, PrefixClose lipgloss.Style
+
 	// Time defines the style used for the time of a log record.
 	//
 	// If ReplaceAttr is used to change the time attribute,
 	// the style is also used for the replacement value.
 	Time lipgloss.Style
 
+	// TimeDelta is the style used for the time since the previous record
+	// if HandlerOptions.ShowTimeDelta is set.
+	TimeDelta lipgloss.Style
+
+	// GoroutineID is the style used for the goroutine ID of a log record
+	// if HandlerOptions.ShowGoroutineID is set.
+	GoroutineID lipgloss.Style
+
+	// ColumnRule is the vertical rule between the metadata column
+	// and the message if HandlerOptions.MetadataColumnWidth is set.
+	//
+	// The default value is "│".
+	// If this is empty, "│" is used.
+	ColumnRule lipgloss.Style
+
+	// Banner is the style used for lines written with Handler.Banner.
+	Banner lipgloss.Style
+
+	// MultilineMarker is the marker written after the message
+	// of records with multi-line attributes
+	// if HandlerOptions.MultilineMarker is set.
+	//
+	// The default value is "⤷".
+	// If this is empty, "⤷" is used.
+	MultilineMarker lipgloss.Style
+
+	// MoreAttrs is the style used for the marker, e.g. "…(+3 more)",
+	// that replaces attributes omitted because of HandlerOptions.MaxAttrs.
+	MoreAttrs lipgloss.Style
+
 	// Messages defines styling for messages logged at different levels.
 	//
 	// If a log record has a level that is not present in this map,
 	// the message will use plain text style.
 	Messages map[slog.Level]lipgloss.Style
 
+	// Lines defines styling for entire lines of log records
+	// at different levels.
+	//
+	// The style is applied to each physical line of the record
+	// after it has been assembled,
+	// so it applies in addition to other styles:
+	// for example, a foreground color set here
+	// will tint all text that doesn't set its own foreground color.
+	//
+	// If a log record has a level that is not present in this map,
+	// no additional styling is applied.
+	// Neither DefaultStyle nor PlainStyle set this.
+	Lines map[slog.Level]lipgloss.Style
+
+	// AttrsContainer defines styling for the attributes of a record
+	// as a whole, e.g. to give them a background color
+	// that sets them apart from the message.
+	//
+	// The style is applied to each line of the attributes
+	// after they have been assembled,
+	// so styles of individual keys and values still apply.
+	// Neither DefaultStyle nor PlainStyle set this.
+	AttrsContainer lipgloss.Style
+
 	// Values defines the styling for attributes matched by their keys.
 	// Attributes with keys that are not present in this map
-	// will use a plain text style for their values.
+	// will use a plain text style for their values,
+	// or the Error style if they are error attributes.
 	//
-	// DefaultStyle uses this to style the "error" key in red.
+	// DefaultStyle does not have entries for "error" and "err" here:
+	// they're styled with Error because they're listed in ErrorKeys.
+	// To change how they're styled, set Error or add entries to this map;
+	// to stop highlighting them, remove them from ErrorKeys.
 	Values map[string]lipgloss.Style
+
+	// ValuesByLevel defines the styling for attributes
+	// matched by the level of the record (after any level offset)
+	// and their keys.
+	// For example, this renders "error" values in a muted red
+	// on WRN records:
+	//
+	//	style.ValuesByLevel = map[slog.Level]map[string]lipgloss.Style{
+	//		slog.LevelWarn: {"error": lipgloss.NewStyle().Foreground(lipgloss.Color("1"))},
+	//	}
+	//
+	// Attributes without an entry for their level and key
+	// use the style in Values.
+	// Neither DefaultStyle nor PlainStyle set this.
+	ValuesByLevel map[slog.Level]map[string]lipgloss.Style
+
+	// KeyValueDelimiters overrides KeyValueDelimiter
+	// for attributes matched by their keys.
+	// For example, to render "status: 200" but "path=/":
+	//
+	//	style.KeyValueDelimiters = map[string]lipgloss.Style{
+	//		"status": lipgloss.NewStyle().SetString(": "),
+	//	}
+	//
+	// Keys are matched like Values:
+	// by the attribute key without its groups,
+	// after ReplaceAttr has been applied.
+	KeyValueDelimiters map[string]lipgloss.Style
+
+	// GroupKeyStyles overrides Key for attributes
+	// matched by the groups they're in.
+	// For example, to color keys in the "db" and "cache" groups:
+	//
+	//	style.GroupKeyStyles = map[string]lipgloss.Style{
+	//		"db":    lipgloss.NewStyle().Foreground(lipgloss.Color("4")),
+	//		"cache": lipgloss.NewStyle().Foreground(lipgloss.Color("5")),
+	//	}
+	//
+	// Groups are matched by their full path (e.g. "db.pool") first,
+	// and then by the top-level group (e.g. "db").
+	// The style applies to the whole key, including group names.
+	GroupKeyStyles map[string]lipgloss.Style
+
+	// BoolGlyphs, if set, renders boolean values as glyphs
+	// instead of "true" and "false".
+	//
+	// Note that output with glyphs cannot be parsed as logfmt.
+	BoolGlyphs BoolGlyphs
+
+	// ValueBars renders a bar after time.Duration values
+	// of attributes matched by their keys,
+	// showing the value as a fraction of a maximum.
+	// For example:
+	//
+	//	style.ValueBars = map[string]silog.ValueBar{
+	//		"latency": {Max: time.Second, Width: 6},
+	//	}
+	//
+	// renders "latency=800ms ████░░ 80%".
+	// Keys are matched like Values.
+	ValueBars map[string]ValueBar
+
+	// Error is the style used for the values of error attributes
+	// (see ErrorKeys) that do not have an entry in Values.
+	//
+	// DefaultStyle uses this to style errors in red.
+	Error lipgloss.Style
+
+	// ErrorKeys lists the keys of attributes that hold errors.
+	// Use SetErrorKeys to add to this list,
+	// or StyleOverrides with ReplaceMaps to replace it.
+	//
+	// Both DefaultStyle and PlainStyle use "error" and "err".
+	ErrorKeys []string
+}
+
+// PrefixFormat specifies how a [Handler] renders prefixes
+// (see [Handler.WithPrefix]).
+type PrefixFormat int
+
+const (
+	// PrefixFormatDelimited separates the prefix from the message
+	// with Style.PrefixDelimiter, e.g. "db: message".
+	PrefixFormatDelimited PrefixFormat = iota
+
+	// PrefixFormatBracketed surrounds the prefix
+	// with Style.PrefixOpen and Style.PrefixClose,
+	// e.g. "[db] message".
+	PrefixFormatBracketed
+)
+
+// BoolGlyphs defines glyphs for boolean values.
+// For example:
+//
+//	style.BoolGlyphs = silog.BoolGlyphs{
+//		True:  lipgloss.NewStyle().SetString("✓").Foreground(lipgloss.Color("10")),
+//		False: lipgloss.NewStyle().SetString("✗").Foreground(lipgloss.Color("9")),
+//	}
+//
+// Glyphs are not used for output with colorprofile.NoTTY
+// or colorprofile.Ascii, with FormatTSV, or if either glyph is empty.
+type BoolGlyphs struct {
+	True, False lipgloss.Style
 }
 
+// ValueBar defines a bar for [Style.ValueBars].
+//
+// For output with colorprofile.NoTTY or colorprofile.Ascii,
+// the bar is drawn with "#" and "-" without styling.
+type ValueBar struct {
+	// Max is the duration of a full bar.
+	// Bars are not rendered if this is not positive.
+	Max time.Duration
+
+	// Width is the number of cells in the bar,
+	// not including the percentage.
+	// Defaults to 10.
+	Width int
+
+	// Style is the style of the bar.
+	Style lipgloss.Style
+}
+
+// RegisterLevel defines a custom level in the style:
+// it sets the label of the level in LevelLabels,
+// its name in LevelNames,
+// and if fg is non-nil, colors the label and messages
+// of the level with it.
+// It returns the style to allow chaining.
+//
+// For example:
+//
+//	const LevelTrace = slog.LevelDebug - 4
+//	style := silog.DefaultStyle().
+//		RegisterLevel(LevelTrace, "TRACE", "TRC", lipgloss.Color("8")).
+//		RegisterLevel(slog.LevelError+4, "FATAL", "FTL", lipgloss.Color("13"))
+func (s *Style) RegisterLevel(lvl slog.Level, name, label string, fg color.Color) *Style {
+	if s.LevelLabels == nil {
+		s.LevelLabels = make(map[slog.Level]lipgloss.Style)
+	}
+	if s.LevelNames == nil {
+		s.LevelNames = make(map[slog.Level]string)
+	}
+	if s.Messages == nil {
+		s.Messages = make(map[slog.Level]lipgloss.Style)
+	}
+
+	labelStyle := lipgloss.NewStyle().SetString(label)
+	if fg != nil {
+		labelStyle = labelStyle.Foreground(fg)
+		s.Messages[lvl] = lipgloss.NewStyle().Foreground(fg)
+	}
+	s.LevelLabels[lvl] = labelStyle
+	s.LevelNames[lvl] = name
+	return s
+}
+
+// ParseLevel parses the name or label of a level.
+// It accepts, in order of precedence:
+//
+//   - names in LevelNames, ignoring case
+//   - labels in LevelLabels, e.g. "WRN"
+//   - names accepted by slog.Level.UnmarshalText,
+//     e.g. "warn" or "INFO+2"
+//
+// For example, with a level registered with [Style.RegisterLevel]
+// as "TRACE", both "trace" and its label parse to that level.
+func (s *Style) ParseLevel(text string) (slog.Level, error) {
+	for lvl, name := range s.LevelNames {
+		if strings.EqualFold(name, text) {
+			return lvl, nil
+		}
+	}
+	for lvl, label := range s.LevelLabels {
+		if text != "" && label.Value() == text {
+			return lvl, nil
+		}
+	}
+
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(text)); err != nil {
+		return 0, fmt.Errorf("unknown level: %q", text)
+	}
+	return lvl, nil
+}
+
+// SetErrorKeys marks attributes with the given keys as error attributes,
+// in addition to those already in ErrorKeys.
+// Values of these attributes are rendered with the Error style
+// in effect when the record is logged.
+//
+// For example, to highlight "cause" and "exception" like "error":
+//
+//	style := silog.DefaultStyle()
+//	style.SetErrorKeys("cause", "exception")
+func (s *Style) SetErrorKeys(keys ...string) {
+	for _, key := range keys {
+		if !slices.Contains(s.ErrorKeys, key) {
+			s.ErrorKeys = append(s.ErrorKeys, key)
+		}
+	}
+}
+
+// errorStyle returns the Error style
+// if key is an error attribute and the Error style is set.
+func (s *Style) errorStyle(key string) (lipgloss.Style, bool) {
+	if !slices.Contains(s.ErrorKeys, key) || styleStart(s.Error) == "" {
+		return lipgloss.Style{}, false
+	}
+	return s.Error, true
+}
+
+// defaultErrorKeys are the error keys of the built-in styles.
+var defaultErrorKeys = []string{"error", "err"}
+
 // DefaultStyle is the default style used by [Handler].
 // It provides colored output, faint text for debug messages, red errors, etc.
 func DefaultStyle() *Style {
 	return &Style{
 		Key:                  lipgloss.NewStyle().Faint(true),
+		AttrDelimiter:        lipgloss.NewStyle().SetString(" "),
 		KeyValueDelimiter:    lipgloss.NewStyle().SetString("=").Faint(true),
 		MultilineValuePrefix: lipgloss.NewStyle().SetString("| ").Faint(true),
+		NullValue:            lipgloss.NewStyle().SetString("null").Faint(true),
 		PrefixDelimiter:      lipgloss.NewStyle().SetString(": "),
 		Time:                 lipgloss.NewStyle().Faint(true),
+		GoroutineID:          lipgloss.NewStyle().Faint(true),
+		TimeDelta:            lipgloss.NewStyle().Faint(true),
+		ColumnRule:           lipgloss.NewStyle().SetString("│").Faint(true),
+		Banner:               lipgloss.NewStyle().Bold(true),
+		MoreAttrs:            lipgloss.NewStyle().Faint(true),
+		MultilineMarker:      lipgloss.NewStyle().SetString("⤷").Faint(true),
 		LevelLabels: map[slog.Level]lipgloss.Style{
 			slog.LevelDebug: lipgloss.NewStyle().SetString("DBG"),                                  // default
 			slog.LevelInfo:  lipgloss.NewStyle().SetString("INF").Foreground(lipgloss.Color("10")), // green
@@ -94,9 +424,9 @@ func DefaultStyle() *Style {
 		Messages: map[slog.Level]lipgloss.Style{
 			slog.LevelDebug: lipgloss.NewStyle().Faint(true),
 		},
-		Values: map[string]lipgloss.Style{
-			"error": lipgloss.NewStyle().Foreground(lipgloss.Color("9")), // red
-		},
+		Values:    map[string]lipgloss.Style{},
+		Error:     lipgloss.NewStyle().Foreground(lipgloss.Color("9")), // red
+		ErrorKeys: slices.Clone(defaultErrorKeys),
 	}
 }
 
@@ -104,17 +434,193 @@ func DefaultStyle() *Style {
 // It's best when writing to a non-terminal destination.
 func PlainStyle() *Style {
 	return &Style{
+		AttrDelimiter:        lipgloss.NewStyle().SetString(" "),
 		KeyValueDelimiter:    lipgloss.NewStyle().SetString("="),
 		MultilineValuePrefix: lipgloss.NewStyle().SetString("  | "),
+		NullValue:            lipgloss.NewStyle().SetString("null"),
 		Time:                 lipgloss.NewStyle(),
 		PrefixDelimiter:      lipgloss.NewStyle().SetString(": "),
+		ColumnRule:           lipgloss.NewStyle().SetString("│"),
+		MultilineMarker:      lipgloss.NewStyle().SetString("⤷"),
 		LevelLabels: map[slog.Level]lipgloss.Style{
 			slog.LevelDebug: lipgloss.NewStyle().SetString("DBG"),
 			slog.LevelInfo:  lipgloss.NewStyle().SetString("INF"),
 			slog.LevelWarn:  lipgloss.NewStyle().SetString("WRN"),
 			slog.LevelError: lipgloss.NewStyle().SetString("ERR"),
 		},
-		Messages: map[slog.Level]lipgloss.Style{},
-		Values:   map[string]lipgloss.Style{},
+		Messages:  map[slog.Level]lipgloss.Style{},
+		Values:    map[string]lipgloss.Style{},
+		ErrorKeys: slices.Clone(defaultErrorKeys),
+	}
+}
+
+// clone returns a copy of the style.
+// Maps in the style are copied so that the copy can be modified freely.
+func (s *Style) clone() *Style {
+	newS := *s
+	newS.LevelLabels = maps.Clone(s.LevelLabels)
+	newS.LevelNames = maps.Clone(s.LevelNames)
+	newS.Messages = maps.Clone(s.Messages)
+	newS.Lines = maps.Clone(s.Lines)
+	newS.Values = maps.Clone(s.Values)
+	if s.ValuesByLevel != nil {
+		newS.ValuesByLevel = make(map[slog.Level]map[string]lipgloss.Style, len(s.ValuesByLevel))
+		for lvl, values := range s.ValuesByLevel {
+			newS.ValuesByLevel[lvl] = maps.Clone(values)
+		}
+	}
+	newS.KeyValueDelimiters = maps.Clone(s.KeyValueDelimiters)
+	newS.GroupKeyStyles = maps.Clone(s.GroupKeyStyles)
+	newS.ValueBars = maps.Clone(s.ValueBars)
+	newS.ErrorKeys = slices.Clone(s.ErrorKeys)
+	return &newS
+}
+
+// StyleOverrides specifies changes to make to a [Style]
+// with [Style.With].
+//
+// Fields that are nil are left unchanged.
+type StyleOverrides struct {
+	Key                  *lipgloss.Style
+	AttrDelimiter        *lipgloss.Style
+	KeyValueDelimiter    *lipgloss.Style
+	MultilineValuePrefix *lipgloss.Style
+	NullValue            *lipgloss.Style
+	PrefixDelimiter      *lipgloss.Style
+	PrefixOpen           *lipgloss.Style
+	PrefixClose          *lipgloss.Style
+	Time                 *lipgloss.Style
+	GoroutineID          *lipgloss.Style
+	TimeDelta            *lipgloss.Style
+	ColumnRule           *lipgloss.Style
+	Banner               *lipgloss.Style
+	MoreAttrs            *lipgloss.Style
+	MultilineMarker      *lipgloss.Style
+	Error                *lipgloss.Style
+	AttrsContainer       *lipgloss.Style
+	PrefixFormat         *PrefixFormat
+	BoolGlyphs           *BoolGlyphs
+
+	// LevelName, if non-nil, replaces the LevelName function of the style.
+	LevelName func(slog.Level) string
+
+	// ErrorKeys are added to the ErrorKeys of the style
+	// as with Style.SetErrorKeys.
+	// If ReplaceMaps is set and this is non-nil,
+	// it replaces the ErrorKeys of the style instead.
+	ErrorKeys []string
+
+	// Entries in these maps are merged into the corresponding maps
+	// of the style, overriding entries with the same keys,
+	// unless ReplaceMaps is set.
+	LevelLabels        map[slog.Level]lipgloss.Style
+	Messages           map[slog.Level]lipgloss.Style
+	Lines              map[slog.Level]lipgloss.Style
+	Values             map[string]lipgloss.Style
+	KeyValueDelimiters map[string]lipgloss.Style
+	GroupKeyStyles     map[string]lipgloss.Style
+	LevelNames         map[slog.Level]string
+	ValueBars          map[string]ValueBar
+
+	// Entries in ValuesByLevel are merged per level:
+	// the map for each level is merged into the style's map for that level.
+	ValuesByLevel map[slog.Level]map[string]lipgloss.Style
+
+	// ReplaceMaps specifies that non-nil maps (and ErrorKeys) in the overrides
+	// replace the corresponding fields of the style entirely
+	// instead of being merged into them.
+	ReplaceMaps bool
+}
+
+// With returns a copy of this style with the given overrides applied.
+// The original style is not modified.
+//
+// For example:
+//
+//	colon := lipgloss.NewStyle().SetString(": ")
+//	style := silog.DefaultStyle().With(silog.StyleOverrides{
+//		KeyValueDelimiter: &colon,
+//		Values: map[string]lipgloss.Style{
+//			"status": lipgloss.NewStyle().Bold(true),
+//		},
+//	})
+func (s *Style) With(overrides StyleOverrides) *Style {
+	newS := s.clone()
+
+	setIfNonNil := func(dst *lipgloss.Style, src *lipgloss.Style) {
+		if src != nil {
+			*dst = *src
+		}
+	}
+	setIfNonNil(&newS.Key, overrides.Key)
+	setIfNonNil(&newS.AttrDelimiter, overrides.AttrDelimiter)
+	setIfNonNil(&newS.KeyValueDelimiter, overrides.KeyValueDelimiter)
+	setIfNonNil(&newS.MultilineValuePrefix, overrides.MultilineValuePrefix)
+	setIfNonNil(&newS.NullValue, overrides.NullValue)
+	setIfNonNil(&newS.PrefixDelimiter, overrides.PrefixDelimiter)
+	setIfNonNil(&newS.PrefixOpen, overrides.PrefixOpen)
+	setIfNonNil(&newS.PrefixClose, overrides.PrefixClose)
+	setIfNonNil(&newS.Time, overrides.Time)
+	setIfNonNil(&newS.GoroutineID, overrides.GoroutineID)
+	setIfNonNil(&newS.TimeDelta, overrides.TimeDelta)
+	setIfNonNil(&newS.ColumnRule, overrides.ColumnRule)
+	setIfNonNil(&newS.Banner, overrides.Banner)
+	setIfNonNil(&newS.MoreAttrs, overrides.MoreAttrs)
+	setIfNonNil(&newS.MultilineMarker, overrides.MultilineMarker)
+	setIfNonNil(&newS.Error, overrides.Error)
+	setIfNonNil(&newS.AttrsContainer, overrides.AttrsContainer)
+	if overrides.PrefixFormat != nil {
+		newS.PrefixFormat = *overrides.PrefixFormat
+	}
+	if overrides.BoolGlyphs != nil {
+		newS.BoolGlyphs = *overrides.BoolGlyphs
+	}
+	if overrides.LevelName != nil {
+		newS.LevelName = overrides.LevelName
+	}
+	if overrides.ReplaceMaps && overrides.ErrorKeys != nil {
+		newS.ErrorKeys = slices.Clone(overrides.ErrorKeys)
+	} else {
+		newS.SetErrorKeys(overrides.ErrorKeys...)
+	}
+
+	newS.LevelLabels = mergeStyles(newS.LevelLabels, overrides.LevelLabels, overrides.ReplaceMaps)
+	newS.Messages = mergeStyles(newS.Messages, overrides.Messages, overrides.ReplaceMaps)
+	newS.Lines = mergeStyles(newS.Lines, overrides.Lines, overrides.ReplaceMaps)
+	newS.Values = mergeStyles(newS.Values, overrides.Values, overrides.ReplaceMaps)
+	newS.KeyValueDelimiters = mergeStyles(newS.KeyValueDelimiters, overrides.KeyValueDelimiters, overrides.ReplaceMaps)
+	newS.GroupKeyStyles = mergeStyles(newS.GroupKeyStyles, overrides.GroupKeyStyles, overrides.ReplaceMaps)
+	newS.LevelNames = mergeStyles(newS.LevelNames, overrides.LevelNames, overrides.ReplaceMaps)
+	newS.ValueBars = mergeStyles(newS.ValueBars, overrides.ValueBars, overrides.ReplaceMaps)
+
+	switch {
+	case overrides.ValuesByLevel == nil:
+		// Nothing to do.
+	case overrides.ReplaceMaps || newS.ValuesByLevel == nil:
+		newS.ValuesByLevel = make(map[slog.Level]map[string]lipgloss.Style, len(overrides.ValuesByLevel))
+		for lvl, values := range overrides.ValuesByLevel {
+			newS.ValuesByLevel[lvl] = maps.Clone(values)
+		}
+	default:
+		for lvl, values := range overrides.ValuesByLevel {
+			newS.ValuesByLevel[lvl] = mergeStyles(newS.ValuesByLevel[lvl], values, false)
+		}
+	}
+	return newS
+}
+
+// mergeStyles merges src into dst and returns the result,
+// or returns a copy of src if replace is set.
+// dst is returned unchanged if src is nil.
+// dst may be modified in-place.
+func mergeStyles[K comparable, V any](dst, src map[K]V, replace bool) map[K]V {
+	switch {
+	case src == nil:
+		return dst
+	case replace || dst == nil:
+		return maps.Clone(src)
+	default:
+		maps.Copy(dst, src)
+		return dst
 	}
 }
@@ -4,9 +4,11 @@ import (
 	"log/slog"
 	"strings"
 	"testing"
+	"time"
 
 	"charm.land/lipgloss/v2"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.abhg.dev/log/silog"
 )
 
@@ -19,9 +21,11 @@ func TestDefaultStyle(t *testing.T) {
 
 	style := silog.DefaultStyle()
 
+	assert.NotEmpty(t, style.AttrDelimiter.Value(), "AttrDelimiter")
 	assertHasValue(t, style.KeyValueDelimiter, "KeyValueDelimiter")
 	assertHasValue(t, style.MultilineValuePrefix, "MultilinePrefix")
 	assertHasValue(t, style.PrefixDelimiter, "PrefixDelimiter")
+	assertHasValue(t, style.NullValue, "NullValue")
 
 	defaultLevels := []slog.Level{
 		slog.LevelDebug,
@@ -36,3 +40,189 @@ func TestDefaultStyle(t *testing.T) {
 		})
 	}
 }
+
+func TestStyle_With(t *testing.T) {
+	base := silog.PlainStyle()
+	base.Values["keep"] = lipgloss.NewStyle().Italic(true)
+
+	colon := lipgloss.NewStyle().SetString(": ")
+	bold := lipgloss.NewStyle().Bold(true)
+
+	t.Run("Merge", func(t *testing.T) {
+		got := base.With(silog.StyleOverrides{
+			KeyValueDelimiter: &colon,
+			LevelLabels: map[slog.Level]lipgloss.Style{
+				slog.LevelInfo: lipgloss.NewStyle().SetString("INFO"),
+			},
+			Values:             map[string]lipgloss.Style{"status": bold},
+			KeyValueDelimiters: map[string]lipgloss.Style{"status": colon},
+			GroupKeyStyles:     map[string]lipgloss.Style{"db": bold},
+		})
+
+		assert.Equal(t, ": ", got.KeyValueDelimiter.Value())
+		assert.Equal(t, base.PrefixDelimiter, got.PrefixDelimiter, "unset fields are unchanged")
+		assert.Equal(t, "INFO", got.LevelLabels[slog.LevelInfo].Value())
+		assert.Equal(t, "ERR", got.LevelLabels[slog.LevelError].Value(), "map entries are merged")
+		assert.Contains(t, got.Values, "keep")
+		assert.Contains(t, got.Values, "status")
+		assert.Contains(t, got.KeyValueDelimiters, "status")
+		assert.Contains(t, got.GroupKeyStyles, "db")
+		assert.Nil(t, got.Lines)
+
+		// Original is unchanged.
+		assert.Equal(t, "=", base.KeyValueDelimiter.Value())
+		assert.Equal(t, "INF", base.LevelLabels[slog.LevelInfo].Value())
+		assert.NotContains(t, base.Values, "status")
+	})
+
+	t.Run("Replace", func(t *testing.T) {
+		got := base.With(silog.StyleOverrides{
+			Values:      map[string]lipgloss.Style{"status": bold},
+			Lines:       map[slog.Level]lipgloss.Style{slog.LevelError: bold},
+			ErrorKeys:   []string{"cause"},
+			ReplaceMaps: true,
+		})
+
+		assert.Equal(t, map[string]lipgloss.Style{"status": bold}, got.Values)
+		assert.Equal(t, map[slog.Level]lipgloss.Style{slog.LevelError: bold}, got.Lines)
+		assert.Equal(t, []string{"cause"}, got.ErrorKeys)
+		assert.Equal(t, base.LevelLabels, got.LevelLabels, "nil maps are not replaced")
+		assert.Contains(t, base.Values, "keep")
+	})
+
+	t.Run("Other", func(t *testing.T) {
+		base := base.With(silog.StyleOverrides{
+			ValuesByLevel: map[slog.Level]map[string]lipgloss.Style{
+				slog.LevelError: {"keep": bold},
+			},
+		})
+
+		bracketed := silog.PrefixFormatBracketed
+		glyphs := silog.BoolGlyphs{True: colon, False: colon}
+		got := base.With(silog.StyleOverrides{
+			PrefixFormat: &bracketed,
+			BoolGlyphs:   &glyphs,
+			LevelName:    func(slog.Level) string { return "lvl" },
+			LevelNames:   map[slog.Level]string{slog.LevelWarn: "WARNING"},
+			ValueBars:    map[string]silog.ValueBar{"latency": {Max: time.Second}},
+			ValuesByLevel: map[slog.Level]map[string]lipgloss.Style{
+				slog.LevelError: {"status": bold},
+			},
+			ErrorKeys: []string{"cause"},
+		})
+
+		assert.Equal(t, silog.PrefixFormatBracketed, got.PrefixFormat)
+		assert.Equal(t, glyphs, got.BoolGlyphs)
+		assert.Equal(t, "lvl", got.LevelName(slog.LevelInfo))
+		assert.Equal(t, map[slog.Level]string{slog.LevelWarn: "WARNING"}, got.LevelNames)
+		assert.Contains(t, got.ValueBars, "latency")
+		assert.Equal(t,
+			map[string]lipgloss.Style{"keep": bold, "status": bold},
+			got.ValuesByLevel[slog.LevelError],
+			"levels are merged")
+		assert.Equal(t, []string{"error", "err", "cause"}, got.ErrorKeys)
+
+		// Original is unchanged.
+		assert.Equal(t, silog.PrefixFormatDelimited, base.PrefixFormat)
+		assert.Nil(t, base.LevelName)
+		assert.NotContains(t, base.ValuesByLevel[slog.LevelError], "status")
+		assert.Equal(t, []string{"error", "err"}, base.ErrorKeys)
+	})
+}
+
+func TestStyle_SetErrorKeys(t *testing.T) {
+	style := silog.DefaultStyle()
+	assert.Equal(t, []string{"error", "err"}, style.ErrorKeys)
+
+	style.SetErrorKeys("cause", "err")
+	assert.Equal(t, []string{"error", "err", "cause"}, style.ErrorKeys)
+
+	t.Run("Plain", func(t *testing.T) {
+		style := silog.PlainStyle()
+		style.SetErrorKeys("exception")
+
+		var buffer strings.Builder
+		log := slog.New(silog.NewHandler(&buffer, &silog.HandlerOptions{
+			Style: style,
+			ReplaceAttr: func(groups []string, attr slog.Attr) slog.Attr {
+				if len(groups) == 0 && attr.Key == slog.TimeKey {
+					return slog.Attr{}
+				}
+				return attr
+			},
+		}))
+
+		log.Error("foo", "exception", "a\tb")
+		assert.Equal(t, "ERR foo  exception=a\tb\n", buffer.String(),
+			"unset Error style leaves values alone")
+
+		// Changes to the style after SetErrorKeys take effect.
+		buffer.Reset()
+		style.Error = lipgloss.NewStyle().Bold(true)
+		style.Values["error"] = lipgloss.NewStyle().Italic(true)
+		log.Error("foo", "exception", "bar", "error", "baz")
+		assert.Equal(t, "ERR foo  exception=\x1b[1mbar\x1b[m error=\x1b[3mbaz\x1b[m\n", buffer.String())
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		var style silog.Style
+		style.SetErrorKeys("error")
+		assert.Equal(t, []string{"error"}, style.ErrorKeys)
+	})
+}
+
+func TestStyle_RegisterLevel(t *testing.T) {
+	const (
+		levelTrace = slog.LevelDebug - 4
+		levelFatal = slog.LevelError + 4
+	)
+	gray := lipgloss.Color("8")
+	style := silog.PlainStyle().
+		RegisterLevel(levelTrace, "TRACE", "TRC", gray).
+		RegisterLevel(levelFatal, "FATAL", "FTL", nil)
+
+	var text, tsv strings.Builder
+	for _, tt := range []struct {
+		w      *strings.Builder
+		format silog.Format
+	}{{&text, silog.FormatText}, {&tsv, silog.FormatTSV}} {
+		log := slog.New(silog.NewHandler(tt.w, &silog.HandlerOptions{
+			Level:       levelTrace,
+			Style:       style,
+			ReplaceAttr: skipTime,
+			Format:      tt.format,
+		}))
+		log.Log(t.Context(), levelTrace, "foo")
+		log.Log(t.Context(), levelFatal, "bar")
+		log.Info("baz")
+	}
+
+	trc := lipgloss.NewStyle().Foreground(gray)
+	assert.Equal(t,
+		trc.Render("TRC")+" "+trc.Render("foo")+"\n"+
+			"FTL bar\n"+
+			"INF baz\n",
+		text.String())
+	assert.Equal(t, "\tTRACE\t\tfoo\t\n\tFATAL\t\tbar\t\n\tINF\t\tbaz\t\n", tsv.String())
+
+	// Names, labels, and slog level names all parse.
+	for _, tt := range []struct {
+		give string
+		want slog.Level
+	}{
+		{"TRACE", levelTrace},
+		{"trace", levelTrace},
+		{"TRC", levelTrace},
+		{"fatal", levelFatal},
+		{"FTL", levelFatal},
+		{"WRN", slog.LevelWarn},
+		{"info+2", slog.LevelInfo + 2},
+	} {
+		lvl, err := style.ParseLevel(tt.give)
+		require.NoError(t, err, tt.give)
+		assert.Equal(t, tt.want, lvl, tt.give)
+	}
+
+	_, err := style.ParseLevel("nope")
+	assert.ErrorContains(t, err, `unknown level: "nope"`)
+}
@@ -0,0 +1,89 @@
+//go:build !windows && !plan9
+
+package silog
+
+import (
+	"log/slog"
+	"log/syslog"
+)
+
+// SyslogWriter writes log records to the system log daemon.
+//
+// It implements [LevelWriter] so that each record is sent to syslog
+// with a severity matching its level:
+//
+//	slog.LevelError and above  -> syslog.LOG_ERR
+//	slog.LevelWarn and above   -> syslog.LOG_WARNING
+//	slog.LevelInfo and above   -> syslog.LOG_INFO
+//	below slog.LevelInfo       -> syslog.LOG_DEBUG
+//
+// Each record is sent as a single syslog message.
+// syslog adds its own timestamp and tag to each message,
+// so it's best to use it with [PlainStyle]
+// and a ReplaceAttr that drops the time.
+//
+// SyslogWriter is not available on Windows and Plan 9.
+type SyslogWriter struct {
+	w *syslog.Writer
+}
+
+var _ LevelWriter = (*SyslogWriter)(nil)
+
+// NewSyslogWriter connects to the system log daemon.
+//
+// priority specifies the syslog facility,
+// and the severity used for writes that don't specify a level.
+// tag is the tag included in each message.
+// If tag is empty, the program name is used.
+func NewSyslogWriter(priority syslog.Priority, tag string) (*SyslogWriter, error) {
+	w, err := syslog.New(priority, tag)
+	if err != nil {
+		return nil, err
+	}
+	return &SyslogWriter{w: w}, nil
+}
+
+// Write sends a message to syslog with the severity
+// specified in NewSyslogWriter.
+func (w *SyslogWriter) Write(p []byte) (int, error) {
+	return w.w.Write(p)
+}
+
+// WriteLevel sends a message to syslog
+// with a severity matching the given level.
+func (w *SyslogWriter) WriteLevel(lvl slog.Level, p []byte) (int, error) {
+	var err error
+	msg := string(p)
+	switch syslogSeverity(lvl) {
+	case syslog.LOG_ERR:
+		err = w.w.Err(msg)
+	case syslog.LOG_WARNING:
+		err = w.w.Warning(msg)
+	case syslog.LOG_INFO:
+		err = w.w.Info(msg)
+	default:
+		err = w.w.Debug(msg)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Close closes the connection to the system log daemon.
+func (w *SyslogWriter) Close() error {
+	return w.w.Close()
+}
+
+func syslogSeverity(lvl slog.Level) syslog.Priority {
+	switch {
+	case lvl >= slog.LevelError:
+		return syslog.LOG_ERR
+	case lvl >= slog.LevelWarn:
+		return syslog.LOG_WARNING
+	case lvl >= slog.LevelInfo:
+		return syslog.LOG_INFO
+	default:
+		return syslog.LOG_DEBUG
+	}
+}
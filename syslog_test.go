@@ -0,0 +1,32 @@
+//go:build !windows && !plan9
+
+package silog
+
+import (
+	"log/slog"
+	"log/syslog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSyslogSeverity(t *testing.T) {
+	tests := []struct {
+		lvl  slog.Level
+		want syslog.Priority
+	}{
+		{slog.LevelDebug - 4, syslog.LOG_DEBUG},
+		{slog.LevelDebug, syslog.LOG_DEBUG},
+		{slog.LevelInfo, syslog.LOG_INFO},
+		{slog.LevelInfo + 2, syslog.LOG_INFO},
+		{slog.LevelWarn, syslog.LOG_WARNING},
+		{slog.LevelError, syslog.LOG_ERR},
+		{slog.LevelError + 4, syslog.LOG_ERR},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.lvl.String(), func(t *testing.T) {
+			assert.Equal(t, tt.want, syslogSeverity(tt.lvl))
+		})
+	}
+}
@@ -0,0 +1,264 @@
+package silog
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"io"
+	"log/slog"
+	"slices"
+	"sync"
+)
+
+// TreeHandler is a handler that collects log records
+// and renders them as a tree when flushed.
+//
+// Each group (see [slog.Logger.WithGroup])
+// and prefix (see [TreeHandler.WithPrefix])
+// becomes a branch of the tree,
+// and records are placed in the branch of the handler
+// that logged them.
+// For example:
+//
+//	tree := silog.NewTreeHandler(os.Stderr, nil)
+//	log := slog.New(tree)
+//	log.Info("start")
+//	payment := log.WithGroup("payment")
+//	payment.Info("charging", "amount", 5)
+//	payment.Warn("retrying")
+//	log.Info("done")
+//	tree.Flush()
+//
+// Renders:
+//
+//	3:04PM INF start
+//	payment
+//	├── 3:04PM INF charging  amount=5
+//	└── 3:04PM WRN retrying
+//	3:04PM INF done
+//
+// Use it to debug the lifecycle of a single operation,
+// e.g. with one TreeHandler per request.
+//
+// Handlers derived from a TreeHandler add to the same tree.
+type TreeHandler struct {
+	h    *Handler
+	tree *logTree
+	path []string // branch that records go into
+}
+
+var _ slog.Handler = (*TreeHandler)(nil)
+
+// NewTreeHandler builds a TreeHandler that writes to w
+// when flushed.
+//
+// Records are rendered with a [Handler] built from opts,
+// except that LevelWriters, DetailWriter, and WriteTimeout are ignored.
+// Branches are drawn with box-drawing characters,
+// or with ASCII characters if opts.ColorProfile
+// is colorprofile.NoTTY or colorprofile.Ascii.
+func NewTreeHandler(w io.Writer, opts *HandlerOptions) *TreeHandler {
+	newOpts := *cmp.Or(opts, &HandlerOptions{})
+	newOpts.LevelWriters = nil
+	newOpts.DetailWriter = nil
+	newOpts.WriteTimeout = 0
+
+	tree := &logTree{
+		out:      w,
+		branches: unicodeBranches,
+	}
+	if !hasColor(newOpts.ColorProfile) {
+		tree.branches = asciiBranches
+	}
+	return &TreeHandler{
+		h:    NewHandler(&treeBranch{tree: tree}, &newOpts),
+		tree: tree,
+	}
+}
+
+// Enabled reports whether the handler is enabled for the given level.
+func (h *TreeHandler) Enabled(ctx context.Context, lvl slog.Level) bool {
+	return h.h.Enabled(ctx, lvl)
+}
+
+// Handle renders a log record and adds it to the tree.
+// The record is not written until Flush is called.
+func (h *TreeHandler) Handle(ctx context.Context, rec slog.Record) error {
+	// The Handler writes the record to the treeBranch for h.path,
+	// which adds it to the tree.
+	// The tree is not locked while the record is rendered
+	// so that hooks may log to the same tree.
+	return h.h.Handle(ctx, rec)
+}
+
+// WithAttrs returns a new handler with the given attributes.
+// Records logged with it are placed in the same branch.
+func (h *TreeHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	newH := *h
+	newH.h = h.h.WithAttrs(attrs).(*Handler)
+	return &newH
+}
+
+// WithGroup returns a new handler that places records
+// in a branch with the given name
+// inside the branch of this handler.
+//
+// Unlike [Handler.WithGroup], attributes are not qualified
+// with the group name, as the branch already shows it.
+// The group is still passed to ReplaceAttr for attributes.
+func (h *TreeHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	newH := h.WithPrefix(name)
+	newH.h.replaceGroups = append(slices.Clip(h.h.replaceGroups), name)
+	return newH
+}
+
+// WithPrefix returns a new handler that places records
+// in a branch with the given name
+// inside the branch of this handler.
+func (h *TreeHandler) WithPrefix(prefix string) *TreeHandler {
+	newH := *h
+	newH.path = append(slices.Clip(h.path), prefix)
+
+	inner := *h.h
+	inner.out = &treeBranch{tree: h.tree, path: newH.path}
+	newH.h = &inner
+	return &newH
+}
+
+// Flush writes the collected records to the output as a tree
+// in a single Write call, and clears the tree.
+func (h *TreeHandler) Flush() error {
+	t := h.tree
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var buf bytes.Buffer
+	t.root.render(&buf, t.branches, nil, true)
+	t.root = treeNode{}
+	if buf.Len() == 0 {
+		return nil
+	}
+
+	_, err := t.out.Write(buf.Bytes())
+	return err
+}
+
+// logTree is the state shared by a TreeHandler and handlers derived from it.
+type logTree struct {
+	mu       sync.Mutex
+	out      io.Writer
+	branches treeBranches
+	root     treeNode
+}
+
+// treeBranch is the output of the Handler used by a TreeHandler.
+// The Handler writes each record in a single Write call,
+// which adds the record to the branch at path.
+type treeBranch struct {
+	tree *logTree
+	path []string
+}
+
+func (b *treeBranch) Write(p []byte) (int, error) {
+	t := b.tree
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	node := t.root.branch(b.path)
+	node.entries = append(node.entries, treeEntry{
+		record: bytes.TrimSuffix(bytes.Clone(p), []byte("\n")),
+	})
+	return len(p), nil
+}
+
+// treeNode is a branch of a log tree.
+type treeNode struct {
+	name    string
+	entries []treeEntry // in the order they were added
+}
+
+// treeEntry is either a record or a child branch.
+type treeEntry struct {
+	record []byte
+	child  *treeNode
+}
+
+// branch returns the node at the given path below n,
+// creating it if necessary.
+func (n *treeNode) branch(path []string) *treeNode {
+	for _, name := range path {
+		idx := slices.IndexFunc(n.entries, func(e treeEntry) bool {
+			return e.child != nil && e.child.name == name
+		})
+		if idx < 0 {
+			idx = len(n.entries)
+			n.entries = append(n.entries, treeEntry{child: &treeNode{name: name}})
+		}
+		n = n.entries[idx].child
+	}
+	return n
+}
+
+// treeBranches are the strings used to draw a tree.
+type treeBranches struct {
+	// middle and last precede the first line of entries
+	// that are not and are the last entry of a branch.
+	middle, last string
+
+	// middleCont and lastCont precede the lines that follow.
+	middleCont, lastCont string
+}
+
+var (
+	unicodeBranches = treeBranches{
+		middle:     "├── ",
+		last:       "└── ",
+		middleCont: "│   ",
+		lastCont:   "    ",
+	}
+	asciiBranches = treeBranches{
+		middle:     "|-- ",
+		last:       "`-- ",
+		middleCont: "|   ",
+		lastCont:   "    ",
+	}
+)
+
+// render writes the entries of n to buf,
+// with each line preceded by indent.
+// Entries of the root node are not preceded by branches.
+func (n *treeNode) render(buf *bytes.Buffer, b treeBranches, indent []byte, root bool) {
+	for i, e := range n.entries {
+		var first, cont string
+		switch {
+		case root:
+			// No branches.
+		case i == len(n.entries)-1:
+			first, cont = b.last, b.lastCont
+		default:
+			first, cont = b.middle, b.middleCont
+		}
+
+		text := e.record
+		if e.child != nil {
+			text = []byte(e.child.name)
+		}
+		for j, line := range bytes.Split(text, []byte("\n")) {
+			buf.Write(indent)
+			if j == 0 {
+				buf.WriteString(first)
+			} else {
+				buf.WriteString(cont)
+			}
+			buf.Write(line)
+			buf.WriteByte('\n')
+		}
+
+		if e.child != nil {
+			e.child.render(buf, b, append(slices.Clip(indent), cont...), false)
+		}
+	}
+}
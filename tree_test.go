@@ -0,0 +1,159 @@
+package silog_test
+
+import (
+	"log/slog"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/colorprofile"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.abhg.dev/log/silog"
+)
+
+func TestTreeHandler(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile colorprofile.Profile
+		want    []string
+	}{
+		{
+			name:    "Unicode",
+			profile: colorprofile.TrueColor,
+			want: []string{
+				"INF start  id=1",
+				"payment",
+				"├── INF charging  id=1 amount=5",
+				"├── card",
+				"│   ├── INF validating  id=1",
+				"│   └── ERR declined  id=1",
+				"│         reason=",
+				"│           | insufficient",
+				"│           | funds",
+				"└── WRN retrying  id=1",
+				"INF done  id=1",
+			},
+		},
+		{
+			name:    "Ascii",
+			profile: colorprofile.Ascii,
+			want: []string{
+				"INF start  id=1",
+				"payment",
+				"|-- INF charging  id=1 amount=5",
+				"|-- card",
+				"|   |-- INF validating  id=1",
+				"|   `-- ERR declined  id=1",
+				"|         reason=",
+				"|           | insufficient",
+				"|           | funds",
+				"`-- WRN retrying  id=1",
+				"INF done  id=1",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buffer strings.Builder
+			tree := silog.NewTreeHandler(&buffer, &silog.HandlerOptions{
+				Style:        silog.PlainStyle(),
+				ReplaceAttr:  skipTime,
+				ColorProfile: tt.profile,
+			})
+
+			log := slog.New(tree).With("id", 1)
+			log.Info("start")
+			payment := log.WithGroup("payment")
+			payment.Info("charging", "amount", 5)
+			payment.WithGroup("card").Info("validating")
+			payment.Debug("not logged")
+			payment.WithGroup("card").Error("declined", "reason", "insufficient\nfunds")
+			payment.Warn("retrying")
+			log.Info("done")
+
+			assert.Empty(t, buffer.String(), "nothing written before Flush")
+			require.NoError(t, tree.Flush())
+			assert.Equal(t, strings.Join(tt.want, "\n")+"\n", buffer.String())
+
+			// Flush clears the tree.
+			buffer.Reset()
+			require.NoError(t, tree.Flush())
+			assert.Empty(t, buffer.String())
+		})
+	}
+}
+
+func TestTreeHandler_withPrefix(t *testing.T) {
+	var buffer strings.Builder
+	tree := silog.NewTreeHandler(&buffer, &silog.HandlerOptions{
+		Style:        silog.PlainStyle(),
+		ReplaceAttr:  skipTime,
+		ColorProfile: colorprofile.Ascii,
+	})
+
+	slog.New(tree.WithPrefix("request")).Info("foo")
+	slog.New(tree.WithPrefix("request").WithPrefix("db")).Info("bar")
+	slog.New(tree.WithPrefix("request")).Info("baz")
+	require.NoError(t, tree.Flush())
+
+	assert.Equal(t, strings.Join([]string{
+		"request",
+		"|-- INF foo",
+		"|-- db",
+		"|   `-- INF bar",
+		"`-- INF baz",
+	}, "\n")+"\n", buffer.String())
+}
+
+func TestTreeHandler_hookLogs(t *testing.T) {
+	var (
+		buffer strings.Builder
+		log    *slog.Logger
+	)
+	tree := silog.NewTreeHandler(&buffer, &silog.HandlerOptions{
+		Style:       silog.PlainStyle(),
+		ReplaceAttr: skipTime,
+		LevelHook: silog.LevelHook{
+			Level: slog.LevelError,
+			Func: func(slog.Record) {
+				// Must not deadlock.
+				log.Info("hook called")
+			},
+		},
+	})
+	log = slog.New(tree)
+
+	log.Error("foo")
+	require.NoError(t, tree.Flush())
+	assert.Equal(t, "ERR foo\nINF hook called\n", buffer.String())
+}
+
+func TestTreeHandler_replaceAttrGroups(t *testing.T) {
+	var (
+		buffer strings.Builder
+		groups [][]string
+	)
+	tree := silog.NewTreeHandler(&buffer, &silog.HandlerOptions{
+		Style: silog.PlainStyle(),
+		ReplaceAttr: func(gs []string, attr slog.Attr) slog.Attr {
+			if attr.Key == "k" {
+				groups = append(groups, slices.Clone(gs))
+			}
+			return skipTime(gs, attr)
+		},
+		ColorProfile: colorprofile.Ascii,
+	})
+
+	log := slog.New(tree.WithPrefix("request")).WithGroup("db")
+	log.With("k", 1).Info("foo", slog.Group("g", "k", 2))
+	require.NoError(t, tree.Flush())
+
+	assert.Equal(t, [][]string{{"db"}, {"db", "g"}}, groups)
+	assert.Equal(t, strings.Join([]string{
+		"request",
+		"`-- db",
+		"    `-- INF foo  k=1 g.k=2",
+	}, "\n")+"\n", buffer.String())
+}
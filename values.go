@@ -0,0 +1,687 @@
+package silog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"log/slog"
+	"math"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// AnyFormat specifies how [Handler] renders
+// structs, maps, slices, and arrays.
+type AnyFormat int
+
+const (
+	// AnyFormatGoInline renders values with the default fmt formatting,
+	// e.g. "{foo 42}" for a struct or "[1 2 3]" for a slice.
+	AnyFormatGoInline AnyFormat = iota
+
+	// AnyFormatJSON renders values as compact JSON,
+	// e.g. `{"Name":"foo","Age":42}` for a struct or "[1,2,3]" for a slice.
+	//
+	// JSON longer than 80 bytes is indented over multiple lines
+	// and rendered as a multi-line value.
+	// Values that cannot be encoded as JSON
+	// are rendered as with AnyFormatGoInline.
+	AnyFormatJSON
+)
+
+// DurationFormat specifies how [Handler] renders time.Duration values.
+type DurationFormat int
+
+const (
+	// DurationFormatGo renders durations with time.Duration.String,
+	// e.g. "450ns", "1.2ms", or "1m2.5s".
+	DurationFormatGo DurationFormat = iota
+
+	// DurationFormatAdaptive renders durations in a single unit
+	// (ns, µs, ms, s, m, or h)
+	// with up to three significant figures,
+	// e.g. "450ns", "1.2ms", "2.5s", or "1.04m".
+	// The unit is the largest one in which the duration is at least 1,
+	// after rounding.
+	DurationFormatAdaptive
+)
+
+// durationUnits are the units of DurationFormatAdaptive
+// from smallest to largest.
+var durationUnits = []struct {
+	name string
+	size time.Duration
+}{
+	{"ns", time.Nanosecond},
+	{"µs", time.Microsecond},
+	{"ms", time.Millisecond},
+	{"s", time.Second},
+	{"m", time.Minute},
+	{"h", time.Hour},
+}
+
+// appendAdaptiveDuration appends d to dst in DurationFormatAdaptive.
+func appendAdaptiveDuration(dst []byte, d time.Duration) []byte {
+	if d == 0 {
+		return append(dst, "0s"...)
+	}
+	if d < 0 {
+		dst = append(dst, '-')
+	}
+	// Use float64 so that math.MinInt64 can be negated.
+	abs := math.Abs(float64(d))
+
+	// Pick the largest unit that the duration is at least one of.
+	unit := 0
+	for unit+1 < len(durationUnits) && abs >= float64(durationUnits[unit+1].size) {
+		unit++
+	}
+
+	v, decimals := roundSignificant(abs / float64(durationUnits[unit].size))
+	if unit+1 < len(durationUnits) {
+		// Rounding may carry into the next unit,
+		// e.g. 999.7µs rounds to 1000µs, which is 1ms.
+		next := durationUnits[unit+1].size / durationUnits[unit].size
+		if v >= float64(next) {
+			unit++
+			v, decimals = roundSignificant(v / float64(next))
+		}
+	}
+
+	num := strconv.AppendFloat(dst, v, 'f', decimals, 64)
+	if decimals > 0 {
+		num = bytes.TrimRight(num, "0")
+		num = bytes.TrimSuffix(num, []byte("."))
+	}
+	return append(num, durationUnits[unit].name...)
+}
+
+// roundSignificant rounds v >= 1 to three significant figures,
+// returning the rounded value and the number of decimal places it needs.
+// Values with more than three integer digits are rounded to an integer.
+func roundSignificant(v float64) (float64, int) {
+	decimals := 0
+	switch {
+	case v < 10:
+		decimals = 2
+	case v < 100:
+		decimals = 1
+	}
+	scale := math.Pow10(decimals)
+	return math.Round(v*scale) / scale, decimals
+}
+
+// maxInlineJSON is the maximum length of JSON values
+// rendered on a single line with AnyFormatJSON.
+const maxInlineJSON = 80
+
+// isComposite reports whether v is a struct, map, slice, or array.
+func isComposite(v any) bool {
+	switch reflect.Indirect(reflect.ValueOf(v)).Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array:
+		return true
+	default:
+		return false
+	}
+}
+
+// unwrapNullable reports whether v is a nullable value:
+// a pointer to a basic type (e.g. *bool),
+// or one of the Null types of database/sql
+// (e.g. sql.NullString, sql.Null[T]).
+// Other structs with a Valid field are not nullable:
+// they may hold more than the value and whether it's present.
+// If so, it returns the wrapped value and whether it is present.
+//
+// Values that implement fmt.Formatter, fmt.Stringer, error,
+// or encoding.TextMarshaler are not nullable as they know how to render themselves.
+func unwrapNullable(v any) (inner any, valid, ok bool) {
+	switch v.(type) {
+	case fmt.Formatter, fmt.Stringer, error, encoding.TextMarshaler:
+		return nil, false, false
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Pointer:
+		if isScalarKind(rv.Type().Elem().Kind()) {
+			if rv.IsNil() {
+				return nil, false, true
+			}
+			return rv.Elem().Interface(), true, true
+		}
+
+	case reflect.Struct:
+		t := rv.Type()
+		if t.PkgPath() != "database/sql" || !strings.HasPrefix(t.Name(), "Null") || t.NumField() != 2 {
+			return nil, false, false
+		}
+		validField, ok := t.FieldByName("Valid")
+		if !ok || validField.Type.Kind() != reflect.Bool || len(validField.Index) != 1 {
+			return nil, false, false
+		}
+
+		valueIdx := 1 - validField.Index[0]
+		if !t.Field(valueIdx).IsExported() {
+			return nil, false, false
+		}
+		if !rv.Field(validField.Index[0]).Bool() {
+			return nil, false, true
+		}
+		return rv.Field(valueIdx).Interface(), true, true
+	}
+
+	return nil, false, false
+}
+
+// isScalarKind reports whether k is a bool, string, or number kind.
+func isScalarKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	default:
+		return false
+	}
+}
+
+// appendJoinedSlice appends the elements of v joined by sep
+// if v is a slice or array of scalars (see isScalarKind).
+// Elements are quoted if they are empty
+// or contain sep, spaces, or quotes.
+// Empty slices are rendered as "[]".
+//
+// It reports false without appending anything if v is not such a slice,
+// or if it implements fmt.Formatter, fmt.Stringer, error,
+// or encoding.TextMarshaler.
+func appendJoinedSlice(dst []byte, v any, sep string) ([]byte, bool) {
+	switch v.(type) {
+	case fmt.Formatter, fmt.Stringer, error, encoding.TextMarshaler:
+		return dst, false
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		if !isScalarKind(rv.Type().Elem().Kind()) {
+			return dst, false
+		}
+	default:
+		return dst, false
+	}
+
+	if rv.Len() == 0 {
+		return append(dst, "[]"...), true
+	}
+	for i := range rv.Len() {
+		if i > 0 {
+			dst = append(dst, sep...)
+		}
+		elem := fmt.Sprint(rv.Index(i).Interface())
+		if elem == "" || strings.Contains(elem, sep) || strings.ContainsFunc(elem, needsQuote) {
+			dst = strconv.AppendQuote(dst, elem)
+		} else {
+			dst = append(dst, elem...)
+		}
+	}
+	return dst, true
+}
+
+// needsQuote reports whether a joined slice element
+// containing r must be quoted.
+func needsQuote(r rune) bool {
+	return r == '"' || unicode.IsSpace(r) || !unicode.IsPrint(r)
+}
+
+// toValidUTF8 replaces invalid UTF-8 sequences in s
+// with the Unicode replacement character.
+func toValidUTF8(s string) string {
+	if utf8.ValidString(s) {
+		return s
+	}
+	return strings.ToValidUTF8(s, string(utf8.RuneError))
+}
+
+var attrSliceType = reflect.TypeFor[[]slog.Attr]()
+
+// attrSliceGroup reports whether v holds a []slog.Attr,
+// or a named type with that underlying type,
+// and if so, returns a group value holding those attributes.
+func attrSliceGroup(v slog.Value) (slog.Value, bool) {
+	if v.Kind() != slog.KindAny {
+		return v, false
+	}
+
+	if attrs, ok := v.Any().([]slog.Attr); ok {
+		return slog.GroupValue(attrs...), true
+	}
+
+	rv := reflect.ValueOf(v.Any())
+	if rv.Kind() != reflect.Slice || !rv.Type().ConvertibleTo(attrSliceType) {
+		return v, false
+	}
+	attrs := rv.Convert(attrSliceType).Interface().([]slog.Attr)
+	return slog.GroupValue(attrs...), true
+}
+
+// appendJSON appends the JSON encoding of v to bs.
+// It reports false if v could not be encoded.
+func appendJSON(bs []byte, v any) ([]byte, bool) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return bs, false
+	}
+
+	if len(data) <= maxInlineJSON {
+		return append(bs, data...), true
+	}
+
+	var buf bytes.Buffer
+	buf.Grow(len(data) * 2)
+	if err := json.Indent(&buf, data, "", "  "); err != nil {
+		return append(bs, data...), true
+	}
+	return append(bs, buf.Bytes()...), true
+}
+
+// Block returns a slog.Value that is always rendered
+// as a multi-line value by [Handler]:
+// on its own indented line(s) below its key,
+// even if it does not contain any newlines.
+//
+//	logger.Info("Running command", "cmd", silog.Block(cmd.String()))
+//
+// This is useful for long single-line values like URLs or commands.
+// The wrapped value is resolved and styled as usual.
+func Block(v any) slog.Value {
+	return slog.AnyValue(blockValue{v: v})
+}
+
+// blockValue marks a value that must be rendered as a multi-line value.
+//
+// This intentionally does not implement slog.LogValuer
+// so that it survives slog.Value.Resolve.
+type blockValue struct{ v any }
+
+// String renders the wrapped value for handlers other than silog's.
+func (b blockValue) String() string {
+	return fmt.Sprint(b.v)
+}
+
+// Reader returns a slog.Value that renders the contents of r
+// as a multi-line value, like [Block].
+// Use it to attach file contents or command output to a record:
+//
+//	logger.Error("Build failed", "output", silog.Reader(&stderr))
+//
+// [Handler] copies r into the rendered record line by line,
+// without first reading all of it into a separate buffer.
+// The record is still written to the output in a single Write call,
+// so it holds all of r at that point.
+//
+// r can only be read once:
+// the value must be used for only one record,
+// and is rendered in full only the first time the record is rendered.
+// Don't add it with slog.Logger.With,
+// and with [HandlerOptions.DetailWriter],
+// leave its key out of SummaryKeys so that it goes to the detail writer.
+// Errors reading r are rendered as the last line of the value.
+func Reader(r io.Reader) slog.Value {
+	return slog.AnyValue(readerValue{r: r})
+}
+
+// readerValue is a value that is read from an io.Reader when rendered.
+//
+// Like blockValue, this does not implement slog.LogValuer
+// so that it survives slog.Value.Resolve.
+type readerValue struct{ r io.Reader }
+
+// String reads the value for FormatTSV and handlers other than silog's.
+func (v readerValue) String() string {
+	var sb strings.Builder
+	if _, err := io.Copy(&sb, v.r); err != nil {
+		fmt.Fprintf(&sb, "\n"+readerErrorFormat, err)
+	}
+	return sb.String()
+}
+
+// readerErrorFormat is the format of the line
+// reporting an error reading a Reader value.
+const readerErrorFormat = "<error reading value: %v>"
+
+// readerLines returns an iterator over the lines of r.
+// Lines end at "\n", "\r\n", or a lone "\r", like valueLines.
+// The yielded lines do not include line endings,
+// and are only valid until the next iteration.
+// If reading fails, the last line reports the error.
+func (f *attrFormatter) readerLines(r io.Reader) iter.Seq[[]byte] {
+	return func(yield func([]byte) bool) {
+		br := bufio.NewReader(r)
+		var (
+			line    []byte
+			afterCR bool // last line ended with "\r" at the end of a chunk
+		)
+		emit := func() bool {
+			out := line
+			if f.sanitizeUTF8 {
+				out = bytes.ToValidUTF8(out, []byte(string(utf8.RuneError)))
+			}
+			if f.stripANSI {
+				out = []byte(ansi.Strip(string(out)))
+			}
+			line = line[:0]
+			return yield(out)
+		}
+
+		for {
+			chunk, err := br.ReadSlice('\n')
+			if afterCR && len(chunk) > 0 {
+				// "\r\n" split across chunks.
+				chunk = bytes.TrimPrefix(chunk, []byte("\n"))
+				afterCR = false
+			}
+			for len(chunk) > 0 {
+				idx := bytes.IndexAny(chunk, "\r\n")
+				if idx < 0 {
+					line = append(line, chunk...)
+					break
+				}
+
+				line = append(line, chunk[:idx]...)
+				if !emit() {
+					return
+				}
+				if chunk[idx] == '\r' {
+					switch {
+					case idx+1 == len(chunk):
+						afterCR = true
+					case chunk[idx+1] == '\n':
+						idx++
+					}
+				}
+				chunk = chunk[idx+1:]
+			}
+
+			switch {
+			case err == nil, errors.Is(err, bufio.ErrBufferFull):
+				// Next chunk.
+			case errors.Is(err, io.EOF):
+				if len(line) > 0 {
+					emit()
+				}
+				return
+			default:
+				if len(line) > 0 && !emit() {
+					return
+				}
+				yield(fmt.Appendf(line[:0], readerErrorFormat, err))
+				return
+			}
+		}
+	}
+}
+
+// Lazy returns a slog.Value that calls fn to get the value
+// only when a handler renders it.
+// Use this to avoid expensive computation for records
+// that are not logged because their level is not enabled:
+//
+//	logger.Debug("Loaded config", "config", silog.Lazy(func() any {
+//		return cfg.Dump() // expensive
+//	}))
+//
+// fn is called each time the value is rendered,
+// e.g. once per record if it's added with slog.Logger.With.
+// It may return a slog.Value, including one built with [Block].
+//
+// Lazy is a slog.LogValuer, so it works with any slog.Handler.
+func Lazy(fn func() any) slog.Value {
+	return slog.AnyValue(lazyValue(fn))
+}
+
+// lazyValue is a slog.LogValuer that calls a function for its value.
+type lazyValue func() any
+
+func (fn lazyValue) LogValue() slog.Value {
+	return slog.AnyValue(fn())
+}
+
+// Bytes returns a slog.Value that renders a byte count
+// in a human-readable form with IEC units.
+// It is the same as [IECBytes].
+func Bytes(n int64) slog.Value {
+	return IECBytes(n)
+}
+
+// IECBytes returns a slog.Value that renders a byte count
+// with binary (powers of 1024) IEC units:
+// "B", "KiB", "MiB", "GiB", and so on.
+//
+//	IECBytes(1023)    // 1023 B
+//	IECBytes(1024)    // 1 KiB
+//	IECBytes(1572864) // 1.5 MiB
+func IECBytes(n int64) slog.Value {
+	return slog.AnyValue(byteSize{n: n, base: 1024, units: _iecUnits})
+}
+
+// SIBytes returns a slog.Value that renders a byte count
+// with decimal (powers of 1000) SI units:
+// "B", "kB", "MB", "GB", and so on.
+//
+//	SIBytes(999)     // 999 B
+//	SIBytes(1000)    // 1 kB
+//	SIBytes(1500000) // 1.5 MB
+func SIBytes(n int64) slog.Value {
+	return slog.AnyValue(byteSize{n: n, base: 1000, units: _siUnits})
+}
+
+var (
+	_iecUnits = []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB", "EiB"}
+	_siUnits  = []string{"B", "kB", "MB", "GB", "TB", "PB", "EB"}
+)
+
+type byteSize struct {
+	n     int64
+	base  float64
+	units []string // units[i] is base^i bytes
+}
+
+var _ slog.LogValuer = byteSize{}
+
+func (b byteSize) LogValue() slog.Value {
+	return slog.StringValue(b.String())
+}
+
+func (b byteSize) String() string {
+	return formatUnits(float64(b.n), b.base, b.units)
+}
+
+// Rate returns a slog.Value that renders the rate
+// of n bytes transferred over the duration d
+// with decimal SI units per second:
+//
+//	Rate(12_300_000, time.Second)   // 12.3 MB/s
+//	Rate(500, 100*time.Millisecond) // 5 kB/s
+//	Rate(1024, 0)                   // n/a
+//
+// The rate is computed when the value is rendered.
+// If d is not positive, the rate renders as "n/a".
+func Rate(n int64, d time.Duration) slog.Value {
+	return slog.AnyValue(byteRate{n: n, d: d})
+}
+
+type byteRate struct {
+	n int64
+	d time.Duration
+}
+
+var _ slog.LogValuer = byteRate{}
+
+func (r byteRate) LogValue() slog.Value {
+	return slog.StringValue(r.String())
+}
+
+func (r byteRate) String() string {
+	if r.d <= 0 {
+		return "n/a"
+	}
+
+	rate := float64(r.n) / r.d.Seconds()
+	if math.Abs(rate) < 1000 {
+		// Fractional rates below 1 kB/s are rounded
+		// like those with larger units.
+		rate = math.Round(rate*10) / 10
+	}
+	return formatUnits(rate, 1000, _siUnits) + "/s"
+}
+
+// Percent returns a slog.Value that renders a fraction as a percentage
+// with at most one decimal place:
+//
+//	Percent(0.85)   // 85%
+//	Percent(0.1234) // 12.3%
+//	Percent(1.5)    // 150%
+//
+// Use [PercentPrec] to choose the number of decimal places.
+func Percent(f float64) slog.Value {
+	return slog.AnyValue(percent{f: f, prec: 1, trim: true})
+}
+
+// PercentPrec returns a slog.Value that renders a fraction as a percentage
+// with exactly prec decimal places:
+//
+//	PercentPrec(0.85, 1)    // 85.0%
+//	PercentPrec(0.12345, 2) // 12.35%
+//	PercentPrec(0.855, 0)   // 86%
+func PercentPrec(f float64, prec int) slog.Value {
+	return slog.AnyValue(percent{f: f, prec: max(prec, 0)})
+}
+
+type percent struct {
+	f    float64
+	prec int  // number of decimal places
+	trim bool // whether to trim trailing zeros after the decimal point
+}
+
+var _ slog.LogValuer = percent{}
+
+func (p percent) LogValue() slog.Value {
+	return slog.StringValue(p.String())
+}
+
+func (p percent) String() string {
+	s := strconv.FormatFloat(p.f*100, 'f', p.prec, 64)
+	if p.trim && strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	return s + "%"
+}
+
+// formatUnits formats n with the largest unit that keeps it at least 1,
+// where units[i] is base^i.
+// Values with a unit larger than units[0]
+// are rendered with at most one decimal place.
+func formatUnits(n, base float64, units []string) string {
+	var sign string
+	if n < 0 {
+		sign, n = "-", -n
+	}
+
+	if n < base {
+		return sign + strconv.FormatFloat(n, 'f', -1, 64) + " " + units[0]
+	}
+
+	unit := 0
+	for n >= base && unit < len(units)-1 {
+		n /= base
+		unit++
+	}
+
+	// Rounding may produce a value that should use the next unit.
+	// e.g. 1023.97 KiB rounds to 1024.0 KiB, which is 1.0 MiB.
+	s := strconv.FormatFloat(n, 'f', 1, 64)
+	if n >= base-0.05 && unit < len(units)-1 {
+		s = strconv.FormatFloat(n/base, 'f', 1, 64)
+		unit++
+	}
+	s = strings.TrimSuffix(s, ".0")
+
+	return sign + s + " " + units[unit]
+}
+
+// appendGroupedDigits appends a formatted number to dst,
+// separating groups of thousands in its integer part with sep.
+// A leading sign is retained,
+// and the fractional part and exponent, if any, are left as-is.
+func appendGroupedDigits(dst, num []byte, sep string) []byte {
+	if len(num) > 0 && (num[0] == '-' || num[0] == '+') {
+		dst = append(dst, num[0])
+		num = num[1:]
+	}
+
+	// Integer part ends at the first non-digit.
+	end := bytes.IndexFunc(num, func(r rune) bool {
+		return r < '0' || r > '9'
+	})
+	if end < 0 {
+		end = len(num)
+	}
+
+	intPart, rest := num[:end], num[end:]
+	for i, c := range intPart {
+		if i > 0 && (len(intPart)-i)%3 == 0 {
+			dst = append(dst, sep...)
+		}
+		dst = append(dst, c)
+	}
+	return append(dst, rest...)
+}
+
+// splitErrorFields splits trailing logfmt-style key=value pairs
+// from an error message, e.g. "failed op=write path=/x"
+// into "failed" and the pairs op=write and path=/x.
+//
+// To avoid mangling messages that aren't structured,
+// a pair is only recognized if its key is an identifier
+// and its value is non-empty and free of quotes and "=".
+// It reports false if there are no such pairs,
+// if no text is left before them, or if the message is multi-line.
+func splitErrorFields(msg string) (rest string, fields []slog.Attr, ok bool) {
+	if strings.ContainsAny(msg, "\r\n") {
+		return "", nil, false
+	}
+
+	rest = msg
+	for {
+		idx := strings.LastIndexByte(rest, ' ')
+		if idx < 0 {
+			break
+		}
+		key, value, ok := strings.Cut(rest[idx+1:], "=")
+		if !ok || !isPairKey(key) || value == "" || strings.ContainsAny(value, `="'`) {
+			break
+		}
+		fields = append(fields, slog.String(key, value))
+		rest = strings.TrimRight(rest[:idx], " ")
+	}
+	if len(fields) == 0 || rest == "" {
+		return "", nil, false
+	}
+
+	slices.Reverse(fields)
+	return rest, fields, true
+}
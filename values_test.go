@@ -0,0 +1,676 @@
+package silog_test
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"charm.land/lipgloss/v2"
+	"github.com/stretchr/testify/assert"
+	"go.abhg.dev/log/silog"
+)
+
+func TestBlock(t *testing.T) {
+	style := silog.PlainStyle()
+	style.Values["styled"] = lipgloss.NewStyle().Bold(true)
+
+	var buffer strings.Builder
+	log := slog.New(silog.NewHandler(&buffer, &silog.HandlerOptions{
+		Style:       style,
+		ReplaceAttr: skipTime,
+	}))
+
+	log.Info("foo",
+		"cmd", silog.Block("git commit -m 'message'"),
+		"styled", silog.Block(42),
+		"k", "v",
+	)
+
+	assert.Equal(t,
+		"INF foo  \n"+
+			"  cmd=\n"+
+			"    | git commit -m 'message'\n"+
+			"  styled=\n"+
+			"    | \x1b[1m42\x1b[m\n"+
+			"  k=v\n",
+		buffer.String())
+}
+
+func TestBlock_otherHandler(t *testing.T) {
+	var buffer strings.Builder
+	log := slog.New(slog.NewTextHandler(&buffer, &slog.HandlerOptions{
+		ReplaceAttr: skipTime,
+	}))
+
+	log.Info("foo", "cmd", silog.Block("ls -l"))
+	assert.Equal(t, "level=INFO msg=foo cmd=\"ls -l\"\n", buffer.String())
+}
+
+func TestReader(t *testing.T) {
+	long := strings.Repeat("x", 10000)
+
+	tests := []struct {
+		name string
+		give io.Reader
+		want string
+	}{
+		{
+			name: "Lines",
+			give: strings.NewReader("foo\nbar\r\nbaz"),
+			want: "    | foo\n" +
+				"    | bar\n" +
+				"    | baz\n",
+		},
+		{
+			name: "CarriageReturn",
+			give: strings.NewReader("foo\rbar\r\rbaz\r"),
+			want: "    | foo\n" +
+				"    | bar\n" +
+				"    | \n" +
+				"    | baz\n",
+		},
+		{
+			// "\r\n" split across reads of the buffer.
+			name: "SplitCRLF",
+			give: strings.NewReader(strings.Repeat("x", 4095) + "\r\nfoo"),
+			want: "    | " + strings.Repeat("x", 4095) + "\n" +
+				"    | foo\n",
+		},
+		{
+			name: "TrailingNewline",
+			give: strings.NewReader("foo\n"),
+			want: "    | foo\n",
+		},
+		{
+			name: "LongLine",
+			give: strings.NewReader(long + "\nfoo"),
+			want: "    | " + long + "\n" +
+				"    | foo\n",
+		},
+		{
+			name: "Error",
+			give: io.MultiReader(
+				strings.NewReader("foo\nba"),
+				iotest.ErrReader(errors.New("great sadness")),
+			),
+			want: "    | foo\n" +
+				"    | ba\n" +
+				"    | <error reading value: great sadness>\n",
+		},
+		{
+			name: "Empty",
+			give: strings.NewReader(""),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buffer strings.Builder
+			log := slog.New(silog.NewHandler(&buffer, &silog.HandlerOptions{
+				Style:       silog.PlainStyle(),
+				ReplaceAttr: skipTime,
+			}))
+
+			log.Info("foo", "out", silog.Reader(tt.give), "k", "v")
+			assert.Equal(t, "INF foo  \n  out=\n"+tt.want+"  k=v\n", buffer.String())
+		})
+	}
+}
+
+func TestReader_detailWriter(t *testing.T) {
+	var primary, detail strings.Builder
+	log := slog.New(silog.NewHandler(&primary, &silog.HandlerOptions{
+		Style:        silog.PlainStyle(),
+		ReplaceAttr:  skipTime,
+		DetailWriter: &detail,
+	}))
+
+	log.Info("foo", "out", silog.Reader(strings.NewReader("a\nb")))
+	assert.Equal(t, "INF foo  ref=1\n", primary.String())
+	assert.Equal(t, "INF foo  \n  out=\n    | a\n    | b\n  ref=1\n", detail.String())
+}
+
+func TestReader_tsv(t *testing.T) {
+	var buffer strings.Builder
+	log := slog.New(silog.NewHandler(&buffer, &silog.HandlerOptions{
+		Style:       silog.PlainStyle(),
+		ReplaceAttr: skipTime,
+		Format:      silog.FormatTSV,
+	}))
+
+	log.Info("foo", "out", silog.Reader(strings.NewReader("a\nb")))
+	assert.Equal(t, "\tINF\t\tfoo\tout=\"a\\nb\"\n", buffer.String())
+}
+
+func TestReader_otherHandler(t *testing.T) {
+	var buffer strings.Builder
+	log := slog.New(slog.NewTextHandler(&buffer, &slog.HandlerOptions{
+		ReplaceAttr: skipTime,
+	}))
+
+	log.Info("foo", "out", silog.Reader(strings.NewReader("a\nb")))
+	assert.Equal(t, "level=INFO msg=foo out=\"a\\nb\"\n", buffer.String())
+}
+
+func TestBytes(t *testing.T) {
+	tests := []struct {
+		name string
+		give slog.Value
+		want string
+	}{
+		{"IEC/Zero", silog.IECBytes(0), "0 B"},
+		{"IEC/1023", silog.IECBytes(1023), "1023 B"},
+		{"IEC/1024", silog.IECBytes(1024), "1 KiB"},
+		{"IEC/1536", silog.IECBytes(1536), "1.5 KiB"},
+		{"IEC/MiB", silog.IECBytes(1572864), "1.5 MiB"},
+		{"IEC/RoundUp", silog.IECBytes(1024*1024 - 1), "1 MiB"},
+		{"IEC/Negative", silog.IECBytes(-2048), "-2 KiB"},
+		{"IEC/Max", silog.IECBytes(1<<63 - 1), "8 EiB"},
+		{"SI/Zero", silog.SIBytes(0), "0 B"},
+		{"SI/999", silog.SIBytes(999), "999 B"},
+		{"SI/1000", silog.SIBytes(1000), "1 kB"},
+		{"SI/1023", silog.SIBytes(1023), "1 kB"},
+		{"SI/1024", silog.SIBytes(1024), "1 kB"},
+		{"SI/MB", silog.SIBytes(1_500_000), "1.5 MB"},
+		{"SI/GB", silog.SIBytes(2_340_000_000), "2.3 GB"},
+		{"Default", silog.Bytes(1024), "1 KiB"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buffer strings.Builder
+			log := slog.New(silog.NewHandler(&buffer, &silog.HandlerOptions{
+				Style:       silog.PlainStyle(),
+				ReplaceAttr: skipTime,
+			}))
+
+			log.Info("foo", "size", tt.give)
+			assert.Equal(t, "INF foo  size="+tt.want+"\n", buffer.String())
+		})
+	}
+}
+
+func TestRate(t *testing.T) {
+	tests := []struct {
+		name string
+		give slog.Value
+		want string
+	}{
+		{"Zero", silog.Rate(0, time.Second), "0 B/s"},
+		{"Bytes", silog.Rate(512, time.Second), "512 B/s"},
+		{"Fractional", silog.Rate(1, 3*time.Second), "0.3 B/s"},
+		{"MB", silog.Rate(12_300_000, time.Second), "12.3 MB/s"},
+		{"SubSecond", silog.Rate(500, 100*time.Millisecond), "5 kB/s"},
+		{"Minute", silog.Rate(60_000_000, time.Minute), "1 MB/s"},
+		{"ZeroDuration", silog.Rate(1024, 0), "n/a"},
+		{"NegativeDuration", silog.Rate(1024, -time.Second), "n/a"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buffer strings.Builder
+			log := slog.New(silog.NewHandler(&buffer, &silog.HandlerOptions{
+				Style:       silog.PlainStyle(),
+				ReplaceAttr: skipTime,
+			}))
+
+			log.Info("foo", "rate", tt.give)
+			assert.Equal(t, "INF foo  rate="+tt.want+"\n", buffer.String())
+		})
+	}
+}
+
+func TestPercent(t *testing.T) {
+	tests := []struct {
+		name string
+		give slog.Value
+		want string
+	}{
+		{"Zero", silog.Percent(0), "0%"},
+		{"Fraction", silog.Percent(0.85), "85%"},
+		{"Rounded", silog.Percent(0.1234), "12.3%"},
+		{"Whole", silog.Percent(1), "100%"},
+		{"OverOne", silog.Percent(1.5), "150%"},
+		{"Negative", silog.Percent(-0.25), "-25%"},
+		{"Prec/One", silog.PercentPrec(0.85, 1), "85.0%"},
+		{"Prec/Two", silog.PercentPrec(0.12345, 2), "12.35%"},
+		{"Prec/Zero", silog.PercentPrec(0.855, 0), "86%"},
+		{"Prec/Negative", silog.PercentPrec(0.855, -1), "86%"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buffer strings.Builder
+			log := slog.New(silog.NewHandler(&buffer, &silog.HandlerOptions{
+				Style:       silog.PlainStyle(),
+				ReplaceAttr: skipTime,
+			}))
+
+			log.Info("foo", "cpu", tt.give)
+			assert.Equal(t, "INF foo  cpu="+tt.want+"\n", buffer.String())
+		})
+	}
+}
+
+func TestHandler_groupDigits(t *testing.T) {
+	tests := []struct {
+		name string
+		give any
+		want string
+	}{
+		{name: "SmallInt", give: 999, want: "999"},
+		{name: "Thousand", give: 1000, want: "1,000"},
+		{name: "Int", give: 1048576, want: "1,048,576"},
+		{name: "Negative", give: -1048576, want: "-1,048,576"},
+		{name: "NegativeSmall", give: -100, want: "-100"},
+		{name: "MinInt", give: int64(math.MinInt64), want: "-9,223,372,036,854,775,808"},
+		{name: "Uint", give: uint64(math.MaxUint64), want: "18,446,744,073,709,551,615"},
+		{name: "Float", give: 123456.789, want: "123,456.789"},
+		{name: "NegativeFloat", give: -12345.5, want: "-12,345.5"},
+		{name: "Exponent", give: 1.5e+21, want: "1.5e+21"},
+		{name: "Inf", give: math.Inf(-1), want: "-Inf"},
+		{name: "NaN", give: math.NaN(), want: "NaN"},
+		{name: "String", give: "1234567", want: "1234567"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buffer strings.Builder
+			handler := silog.NewHandler(&buffer, &silog.HandlerOptions{
+				Style:       silog.PlainStyle(),
+				ReplaceAttr: skipTime,
+				GroupDigits: true,
+			})
+
+			slog.New(handler).Info("msg", "v", tt.give)
+			assert.Equal(t, "INF msg  v="+tt.want+"\n", buffer.String())
+		})
+	}
+
+	t.Run("Separator", func(t *testing.T) {
+		var buffer strings.Builder
+		handler := silog.NewHandler(&buffer, &silog.HandlerOptions{
+			Style:          silog.PlainStyle(),
+			ReplaceAttr:    skipTime,
+			GroupDigits:    true,
+			DigitSeparator: "_",
+		})
+
+		slog.New(handler).Info("msg", "v", 1048576)
+		assert.Equal(t, "INF msg  v=1_048_576\n", buffer.String())
+	})
+
+	t.Run("TSV", func(t *testing.T) {
+		var buffer strings.Builder
+		handler := silog.NewHandler(&buffer, &silog.HandlerOptions{
+			Style:          silog.PlainStyle(),
+			ReplaceAttr:    skipTime,
+			Format:         silog.FormatTSV,
+			GroupDigits:    true,
+			DigitSeparator: " ",
+		})
+
+		// Values with spaces are quoted.
+		slog.New(handler).Info("msg", "v", 1048576, "w", 1000.5)
+		assert.Equal(t, "\tINF\t\tmsg\tv=\"1 048 576\" w=\"1 000.5\"\n", buffer.String())
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		var buffer strings.Builder
+		handler := silog.NewHandler(&buffer, &silog.HandlerOptions{
+			Style:          silog.PlainStyle(),
+			ReplaceAttr:    skipTime,
+			DigitSeparator: "_",
+		})
+
+		slog.New(handler).Info("msg", "v", 1048576)
+		assert.Equal(t, "INF msg  v=1048576\n", buffer.String())
+	})
+}
+
+type attrBundle []slog.Attr
+
+func TestHandler_attrSlice(t *testing.T) {
+	var buffer strings.Builder
+	handler := silog.NewHandler(&buffer, &silog.HandlerOptions{
+		Style:       silog.PlainStyle(),
+		ReplaceAttr: skipTime,
+	})
+
+	log := slog.New(handler).With("req", []slog.Attr{
+		slog.String("id", "abc"),
+	})
+	log.Info("foo",
+		"user", []slog.Attr{
+			slog.Int("id", 42),
+			slog.Any("name", attrBundle{
+				slog.String("first", "Jane"),
+				slog.String("bio", "line1\nline2"),
+			}),
+		},
+		"empty", []slog.Attr{},
+		"k", "v",
+	)
+
+	assert.Equal(t,
+		"INF foo  req.id=abc user.id=42 user.name.first=Jane\n"+
+			"  user.name.bio=\n"+
+			"    | line1\n"+
+			"    | line2\n"+
+			"  k=v\n",
+		buffer.String())
+}
+
+type nullableName struct {
+	Name  string
+	Valid bool
+}
+
+func TestHandler_nullableValues(t *testing.T) {
+	yes, n := true, 42
+	tests := []struct {
+		name string
+		give any
+		want string
+	}{
+		{name: "NullString", give: sql.NullString{String: "x", Valid: true}, want: "x"},
+		{name: "NullStringInvalid", give: sql.NullString{String: "x"}, want: "null"},
+		{name: "NullInt64", give: sql.NullInt64{Int64: 1000, Valid: true}, want: "1000"},
+		{name: "NullBool", give: sql.NullBool{Bool: false, Valid: true}, want: "false"},
+		{name: "Generic", give: sql.Null[float64]{V: 1.5, Valid: true}, want: "1.5"},
+		{name: "GenericInvalid", give: sql.Null[float64]{}, want: "null"},
+		{name: "Custom", give: nullableName{Name: "foo", Valid: true}, want: "{foo true}"},
+		{name: "CustomInvalid", give: nullableName{Name: "foo"}, want: "{foo false}"},
+		{name: "BoolPointer", give: &yes, want: "true"},
+		{name: "IntPointer", give: &n, want: "42"},
+		{name: "NilBoolPointer", give: (*bool)(nil), want: "null"},
+		{
+			name: "NotNullable",
+			give: struct {
+				A, B  int
+				Valid bool
+			}{1, 2, true},
+			want: "{1 2 true}",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buffer strings.Builder
+			handler := silog.NewHandler(&buffer, &silog.HandlerOptions{
+				Style:       silog.PlainStyle(),
+				ReplaceAttr: skipTime,
+			})
+
+			slog.New(handler).Info("msg", "v", tt.give)
+			assert.Equal(t, "INF msg  v="+tt.want+"\n", buffer.String())
+		})
+	}
+}
+
+func TestHandler_sourceValue(t *testing.T) {
+	src := slog.Source{
+		Function: "example.com/foo.Bar",
+		File:     "/src/foo/bar.go",
+		Line:     42,
+	}
+
+	var buffer strings.Builder
+	log := slog.New(silog.NewHandler(&buffer, &silog.HandlerOptions{
+		Style:       silog.PlainStyle(),
+		ReplaceAttr: skipTime,
+	}))
+
+	log.Info("foo", slog.Any(slog.SourceKey, &src), "caller", src)
+	log.Info("bar", slog.Any(slog.SourceKey, (*slog.Source)(nil)))
+	assert.Equal(t,
+		"INF foo  source=/src/foo/bar.go:42 caller=/src/foo/bar.go:42\n"+
+			"INF bar  source=null\n",
+		buffer.String())
+}
+
+func TestHandler_scalarSliceJoin(t *testing.T) {
+	type point struct{ X, Y int }
+
+	tests := []struct {
+		name string
+		give any
+		want string
+	}{
+		{name: "Strings", give: []string{"a", "b", "c"}, want: "a,b,c"},
+		{name: "Ints", give: []int{1, 2, 3}, want: "1,2,3"},
+		{name: "Array", give: [2]float64{1.5, 2}, want: "1.5,2"},
+		{name: "Bools", give: []bool{true, false}, want: "true,false"},
+		{name: "Single", give: []string{"a"}, want: "a"},
+		{name: "Empty", give: []string{}, want: "[]"},
+		{name: "Nil", give: []string(nil), want: "null"},
+		{name: "Quoted", give: []string{"a,b", "c d", "", `e"f`}, want: `"a,b","c d","","e\"f"`},
+		{name: "NotScalar", give: []point{{1, 2}}, want: "[{1 2}]"},
+		{name: "Stringer", give: net.IP{127, 0, 0, 1}, want: "127.0.0.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buffer strings.Builder
+			log := slog.New(silog.NewHandler(&buffer, &silog.HandlerOptions{
+				Style:           silog.PlainStyle(),
+				ReplaceAttr:     skipTime,
+				ScalarSliceJoin: ",",
+			}))
+
+			log.Info("foo", "tags", tt.give)
+			assert.Equal(t, "INF foo  tags="+tt.want+"\n", buffer.String())
+		})
+	}
+}
+
+func TestHandler_verboseFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		verbose bool
+		want    string
+	}{
+		{name: "Default", want: "INF msg  err=failed\n"},
+		{name: "Verbose", verbose: true, want: "INF msg  err=failed (at main.go:42)\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buffer strings.Builder
+			handler := silog.NewHandler(&buffer, &silog.HandlerOptions{
+				Style:         silog.PlainStyle(),
+				ReplaceAttr:   skipTime,
+				VerboseFormat: tt.verbose,
+			})
+
+			slog.New(handler).Info("msg", "err", tracedError{msg: "failed"})
+			assert.Equal(t, tt.want, buffer.String())
+		})
+	}
+}
+
+// tracedError is an error that reports its location with %+v.
+// Its Error method is not used because fmt.Formatter takes precedence.
+type tracedError struct{ msg string }
+
+func (e tracedError) Error() string { return "error: " + e.msg }
+
+func (e tracedError) Format(f fmt.State, verb rune) {
+	_, _ = io.WriteString(f, e.msg)
+	if verb == 'v' && f.Flag('+') {
+		_, _ = io.WriteString(f, " (at main.go:42)")
+	}
+}
+
+func TestHandler_promoteErrorFields(t *testing.T) {
+	tests := []struct {
+		name string
+		give error
+		want string
+	}{
+		{
+			name: "Fields",
+			give: errors.New("write failed op=write path=/x"),
+			want: "err=write failed op=write path=/x",
+		},
+		{
+			name: "NoFields",
+			give: errors.New("write failed"),
+			want: "err=write failed",
+		},
+		{
+			name: "OnlyFields",
+			give: errors.New("op=write"),
+			want: "err=op=write",
+		},
+		{
+			name: "QuotedValue",
+			give: errors.New(`failed path="a b"`),
+			want: `err=failed path="a b"`,
+		},
+		{
+			name: "InvalidKey",
+			give: errors.New("failed 1+1=2"),
+			want: "err=failed 1+1=2",
+		},
+		{
+			name: "FieldsInMiddle",
+			give: errors.New("failed op=write: disk full code=28"),
+			want: "err=failed op=write: disk full code=28",
+		},
+		{
+			name: "Multiline",
+			give: errors.New("failed\nop=write"),
+			want: "\n  err=\n    | failed\n    | op=write",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buffer strings.Builder
+			handler := silog.NewHandler(&buffer, &silog.HandlerOptions{
+				Style:              silog.PlainStyle(),
+				ReplaceAttr:        skipTime,
+				PromoteErrorFields: true,
+			})
+
+			slog.New(handler).Info("msg", "err", tt.give)
+			assert.Equal(t, "INF msg  "+tt.want+"\n", buffer.String())
+		})
+	}
+
+	t.Run("NotErrorKey", func(t *testing.T) {
+		var buffer strings.Builder
+		handler := silog.NewHandler(&buffer, &silog.HandlerOptions{
+			Style:              silog.PlainStyle(),
+			ReplaceAttr:        skipTime,
+			PromoteErrorFields: true,
+		})
+
+		slog.New(handler).Info("msg", "other", errors.New("failed k=v"))
+		assert.Equal(t, "INF msg  other=failed k=v\n", buffer.String())
+	})
+}
+
+func TestHandler_durationFormatAdaptive(t *testing.T) {
+	tests := []struct {
+		give time.Duration
+		want string
+	}{
+		{0, "0s"},
+		{1, "1ns"},
+		{450 * time.Nanosecond, "450ns"},
+		{999 * time.Nanosecond, "999ns"},
+		{time.Microsecond, "1µs"},
+		{1234 * time.Nanosecond, "1.23µs"},
+		{1235 * time.Nanosecond, "1.24µs"},
+		{12_345 * time.Nanosecond, "12.3µs"},
+		{450 * time.Microsecond, "450µs"},
+		{999_499 * time.Nanosecond, "999µs"},
+		{999_500 * time.Nanosecond, "1ms"}, // rounds into the next unit
+		{1200 * time.Microsecond, "1.2ms"},
+		{9_996 * time.Microsecond, "10ms"},
+		{99_960 * time.Microsecond, "100ms"},
+		{2500 * time.Millisecond, "2.5s"},
+		{59_970 * time.Millisecond, "1m"}, // 60s
+		{62_500 * time.Millisecond, "1.04m"},
+		{90 * time.Minute, "1.5h"},
+		{1500 * time.Hour, "1500h"},
+		{-1200 * time.Microsecond, "-1.2ms"},
+		{math.MinInt64, "-2562048h"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			var buffer strings.Builder
+			handler := silog.NewHandler(&buffer, &silog.HandlerOptions{
+				Style:          silog.PlainStyle(),
+				ReplaceAttr:    skipTime,
+				DurationFormat: silog.DurationFormatAdaptive,
+			})
+
+			slog.New(handler).Info("msg", "d", tt.give)
+			assert.Equal(t, "INF msg  d="+tt.want+"\n", buffer.String())
+		})
+	}
+}
+
+func TestHandler_inlineValueMaxLen(t *testing.T) {
+	var buffer strings.Builder
+	handler := silog.NewHandler(&buffer, &silog.HandlerOptions{
+		Style:             silog.PlainStyle(),
+		ReplaceAttr:       skipTime,
+		InlineValueMaxLen: 10,
+	})
+
+	slog.New(handler).Info("request",
+		"method", "GET",
+		"url", "https://example.com/a/long/path",
+		"status", 200,
+		"body", "0123456789", // not longer than the limit
+	)
+
+	assert.Equal(t,
+		"INF request  method=GET\n"+
+			"  url=\n"+
+			"    | https://example.com/a/long/path\n"+
+			"  status=200 body=0123456789\n",
+		buffer.String())
+}
+
+func TestLazy(t *testing.T) {
+	var calls int
+	value := silog.Lazy(func() any {
+		calls++
+		return silog.Block("expensive")
+	})
+
+	var buffer strings.Builder
+	handler := silog.NewHandler(&buffer, &silog.HandlerOptions{
+		Style:       silog.PlainStyle(),
+		ReplaceAttr: skipTime,
+	})
+	log := slog.New(handler)
+
+	log.Debug("skipped", "v", value)
+	assert.Zero(t, calls, "should not be computed for disabled levels")
+
+	log.Info("logged", "v", value)
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, "INF logged  \n  v=\n    | expensive\n", buffer.String())
+
+	// Other handlers resolve it too.
+	buffer.Reset()
+	slog.New(slog.NewTextHandler(&buffer, &slog.HandlerOptions{
+		ReplaceAttr: skipTime,
+	})).Info("text", "v", value)
+	assert.Equal(t, "level=INFO msg=text v=expensive\n", buffer.String())
+}